@@ -1,8 +1,20 @@
 package buffer
 
 import (
+	"cmp"
+	"context"
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
+	"iter"
+	"math/bits"
+	"math/rand"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
 )
 
 type RingBuffer[T any] interface {
@@ -20,167 +32,2240 @@ type RingBuffer[T any] interface {
 
 var ErrInvalidBuffCap = fmt.Errorf("buffer capacity is less than 1")
 var ErrBufferIsFull = fmt.Errorf("buffer is full")
+var ErrBufferIsEmpty = fmt.Errorf("buffer is empty")
+var ErrClosed = fmt.Errorf("buffer is closed")
+
+// Stats is a point-in-time view of the buffer state and its lifetime
+// counters, as returned by StatsSnapshot.
+type Stats struct {
+	Size        int
+	Capacity    int
+	Pushed      uint64
+	Popped      uint64
+	Overwritten uint64
+}
 
 // ringBuffer is a thread-safe ring buffer implementation.
 type ringBuffer[T any] struct {
 	mu   sync.RWMutex
 	data []T
-	size int
-	cap  int
+	size atomic.Int64
+	cap  atomic.Int64
 
 	writerIdx     int
 	readerIdx     int
 	lastWriterIdx int
-	wrapped       bool
+	// wrapped reports whether the writer has wrapped around the end of the
+	// array ahead of the reader. It stays false with WithNoWrap.
+	wrapped bool
+
+	// pushed, popped and overwritten count the operations over the buffer
+	// lifetime. They're guarded by mu.
+	pushed      uint64
+	popped      uint64
+	overwritten uint64
+	// overflowed counts the overwritten elements as well, but it's reset by
+	// ResetOverflowed. It's guarded by mu.
+	overflowed uint64
+	// seq is the number of elements pushed so far, so the nth pushed element
+	// gets the sequence number n, see HeadSeq. seqs holds the numbers of the
+	// elements by their cells once an operation such as PushFront, PopNewest
+	// or Compact breaks their contiguity. While it's nil, the oldest element
+	// has the number seq-size+1. Both are guarded by mu.
+	seq  uint64
+	seqs []uint64
+	// pos is the position of the newest element in the stream followed by
+	// the subscriptions. Unlike seq, it's decremented when the newest element
+	// is removed or Compact removes elements, so that the positions stay
+	// contiguous and pos-size+1 is the position of the oldest element. It's
+	// guarded by mu.
+	pos uint64
+	// subs holds the subscriptions created by Subscribe. It's guarded by mu.
+	subs map[*Subscription[T]]struct{}
+	// overruns holds the losses of the subscriptions to report to the
+	// OverrunObserver. It's guarded by mu, and hasOverruns tells whether it's
+	// non-empty without locking.
+	overruns    []overrun[T]
+	hasOverruns atomic.Bool
+
+	// notEmptyCond is signaled when an element is added to the buffer.
+	notEmptyCond *sync.Cond
+	// fullCond is signaled when the buffer becomes full.
+	fullCond *sync.Cond
+	// notFullCond is signaled when the buffer stops being full.
+	notFullCond *sync.Cond
+	// notEmpty is closed and replaced when the buffer stops being empty.
+	notEmpty chan struct{}
+	// notFull is closed and replaced when the buffer stops being full.
+	notFull chan struct{}
+	// closed is set by Close. It's guarded by mu.
+	closed bool
+
+	observer Observer
+	// noWrap makes the push methods drop new elements instead of overwriting
+	// when the buffer is full. It's set by WithNoWrap.
+	noWrap bool
+	// block makes the push methods wait while the buffer is full. It's set by
+	// WithOverflow(Block).
+	block bool
+	// maxCap is the capacity up to which a full buffer grows on push instead
+	// of overwriting. It's set by WithAutoGrow and is 0 if growth is disabled.
+	maxCap int
+	// pow2 keeps the capacity a power of two, so the indices can wrap around
+	// with a bitmask instead of a division. It's set by WithPow2Capacity.
+	pow2 bool
+	// dedup reports whether two elements are equal, so that a push of an
+	// element equal to the newest one is skipped. It's set by WithDedup.
+	dedup func(a, b T) bool
+	// flush receives the elements of the buffer when a push fills it, after
+	// which the buffer is cleared. It's set by WithFlushOnFull.
+	flush func([]T)
+	// noZeroOnPop makes the removing methods leave stale elements in the
+	// vacated cells instead of zeroing them. It's set by WithZeroOnPop.
+	noZeroOnPop bool
+	// validate checks the elements before they're pushed. It's set by
+	// WithValidator.
+	validate func(T) error
+	// shrinkFactor is the load factor below which the buffer shrinks after
+	// shrinkPatience consecutive pops. It's set by WithShrinkPolicy and is 0
+	// if shrinking is disabled. lowLoadPops counts the pops that left the
+	// buffer below the factor, it's guarded by mu.
+	shrinkFactor float64
+	lowLoadPops  int
+	// shrunkFrom is the capacity the buffer had before it was shrunk by the
+	// shrink policy, up to which it grows back on push. It's 0 if the buffer
+	// isn't shrunk or its capacity was set explicitly since, e.g. by Resize.
+	// It's guarded by mu.
+	shrunkFrom int
+	// tee receives a copy of every element added by the push methods. It's
+	// set by WithTee.
+	tee *ringBuffer[T]
+	// fillSamples holds the last buffer sizes observed after each push and
+	// pop, if enabled by WithFillSampler.
+	fillSamples *ringBuffer[int]
+	// latency holds the histograms of the Push and Pop durations, if enabled
+	// by WithLatencyHistogram.
+	latency *latencyRecorder
+	// now returns the current time. It's time.Now unless set by WithClock.
+	now func() time.Time
 }
 
 // Push adds an element to the buffer. If the buffer is full, overwrites the
-// oldest element. If the element could not be placed, an error is returned.
+// oldest element, unless the options, such as WithNoWrap or WithAutoGrow,
+// handle a full buffer otherwise. Rejected elements are dropped silently.
 func (rb *ringBuffer[T]) Push(item T) {
+	if rb.latency != nil {
+		defer rb.observeLatency(&rb.latency.push, rb.now())
+	}
+	if err := rb.pushItem(item, false); err == ErrClosed {
+		panic(err)
+	}
+}
+
+// PushValidated works like Push, but returns the error of the validator set by
+// WithValidator if it rejects the element, and ErrClosed if the buffer is
+// closed.
+func (rb *ringBuffer[T]) PushValidated(item T) error {
+	if rb.latency != nil {
+		defer rb.observeLatency(&rb.latency.push, rb.now())
+	}
+	return rb.pushItem(item, false)
+}
+
+// PushFront adds an element to the beginning of the buffer, so that it becomes
+// the oldest element and is returned by the next Pop. If the buffer is full,
+// the newest element is evicted to make room, unless the options handle a
+// full buffer otherwise, as for Push.
+func (rb *ringBuffer[T]) PushFront(item T) {
+	if !rb.isValid(item) {
+		return
+	}
 	rb.mu.Lock()
-	defer rb.mu.Unlock()
-	rb.data[rb.writerIdx] = item
-	rb.lastWriterIdx = rb.writerIdx
-	if rb.size < cap(rb.data) {
-		rb.size++
+	rb.waitNotFull()
+	rb.panicIfClosed()
+	oldCap, newCap := rb.autoGrow()
+	if rb.noWrap && rb.isFull() {
+		rb.mu.Unlock()
+		return
 	}
-	if round := rb.shiftIdx(&rb.writerIdx); round {
+	wasEmpty := rb.size.Load() == 0
+	overwritten := rb.isFull()
+	if overwritten {
+		rb.removeNewest()
+		rb.overwritten++
+		rb.overflowed++
+	}
+	rb.numberSeqs()
+	rb.pushed++
+	rb.seq++
+	rb.pos++
+	if round := rb.unshiftIdx(&rb.readerIdx); round && !rb.noWrap {
 		rb.wrapped = true
 	}
+	rb.data[rb.readerIdx] = item
+	rb.seqs[rb.readerIdx] = rb.seq
+	rb.size.Add(1)
+	if rb.size.Load() == 1 {
+		rb.lastWriterIdx = rb.readerIdx
+	}
+	filled := !overwritten && rb.isFull()
+	rb.sampleFill()
+	rb.wakeNotEmpty(wasEmpty)
+	rb.wakeFull()
+	flushed := rb.flushIfFull(filled)
+	rb.mu.Unlock()
+
+	rb.notifyResize(oldCap, newCap)
+	rb.notifyPush(overwritten, filled)
+	if flushed != nil {
+		rb.flush(flushed)
+	}
+	rb.pushTee(item)
+}
+
+// PushSlice adds all given elements to the buffer, overwriting the oldest
+// elements if the buffer runs out of space, unless the options handle a full
+// buffer otherwise, as for Push. Returns the overwritten elements in the order
+// they were evicted. While WithOverflow(Block) makes it wait for free space,
+// other operations may interleave with the batch.
+func (rb *ringBuffer[T]) PushSlice(items []T) []T {
+	evicted, err := rb.pushSlice(items)
+	if err != nil {
+		panic(err)
+	}
+	return evicted
+}
+
+// pushSlice works like PushSlice, but if the buffer is closed, it returns
+// ErrClosed instead of panicking, after adding the elements that preceded the
+// closing.
+func (rb *ringBuffer[T]) pushSlice(items []T) ([]T, error) {
+	var evicted, added []T
+	var flushed [][]T
+	var filled bool
+	var err error
+	pushed := 0
+	rb.mu.Lock()
+	oldCap := int(rb.cap.Load())
+	for _, item := range items {
+		if !rb.isValid(item) || rb.isDup(item) {
+			continue
+		}
+		rb.waitNotFull()
+		if rb.closed {
+			break
+		}
+		rb.autoGrow()
+		if rb.isFull() {
+			if rb.noWrap {
+				break
+			}
+			evicted = append(evicted, rb.data[rb.writerIdx])
+		}
+		if !rb.push(item) && rb.isFull() {
+			filled = true
+			if items := rb.flushIfFull(true); items != nil {
+				flushed = append(flushed, items)
+			}
+		}
+		pushed++
+		if rb.tee != nil {
+			added = append(added, item)
+		}
+	}
+	if rb.closed {
+		err = ErrClosed
+	}
+	newCap := int(rb.cap.Load())
+	rb.mu.Unlock()
+
+	rb.notifyResize(oldCap, newCap)
+	rb.notifyPushes(pushed, len(evicted), filled)
+	for _, items := range flushed {
+		rb.flush(items)
+	}
+	rb.pushTee(added...)
+	return evicted, err
 }
 
 // TryPush attempts to add an element to the ring buffer. If the buffer is
 // full, it returns ErrBufferFull without adding the element. If there is free
-// space, it adds the element and returns nil.
+// space, it adds the element and returns nil. The check and the insertion are
+// done under a single lock, so concurrent calls never overwrite elements.
+// If the element is rejected by WithValidator, returns the validator error.
 func (rb *ringBuffer[T]) TryPush(item T) (err error) {
-	if rb.IsFull() {
+	return rb.pushItem(item, true)
+}
+
+// PushTimeout works like TryPush, but if the buffer is full, it waits up to d
+// for free space before giving up with ErrBufferIsFull.
+func (rb *ringBuffer[T]) PushTimeout(item T, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		// Get the channel before the attempt, so that a Pop between the
+		// attempt and the wait isn't missed.
+		notFull := rb.NotFull()
+		if err := rb.TryPush(item); err != ErrBufferIsFull {
+			return err
+		}
+		select {
+		case <-notFull:
+		case <-timer.C:
+			return ErrBufferIsFull
+		}
+	}
+}
+
+// Offer adds the given elements in order while there is free space in the
+// buffer, without overwriting. Returns the number of elements taken from
+// items, so the caller can retry the rest, i.e. items[n:], later. The elements
+// rejected by WithValidator or skipped by WithDedup count as taken. If the
+// buffer is closed, returns 0.
+func (rb *ringBuffer[T]) Offer(items []T) int {
+	var added []T
+	var flushed [][]T
+	var filled bool
+	rb.mu.Lock()
+	n := 0
+	for n < len(items) && !rb.isFull() && !rb.closed {
+		if rb.isValid(items[n]) && !rb.isDup(items[n]) {
+			rb.push(items[n])
+			added = append(added, items[n])
+			if rb.isFull() {
+				filled = true
+				if items := rb.flushIfFull(true); items != nil {
+					flushed = append(flushed, items)
+				}
+			}
+		}
+		n++
+	}
+	rb.mu.Unlock()
+
+	rb.notifyPushes(len(added), 0, filled)
+	for _, items := range flushed {
+		rb.flush(items)
+	}
+	rb.pushTee(added...)
+	return n
+}
+
+// TryPushBatch adds all given elements in order if there is enough free
+// space for them. Otherwise, it returns ErrBufferIsFull without adding any of
+// them. The check and the insertion are done under a single lock. If an
+// element is rejected by WithValidator, returns the validator error without
+// adding any of them. The elements skipped by WithDedup need no space.
+func (rb *ringBuffer[T]) TryPushBatch(items []T) error {
+	if rb.validate != nil {
+		for _, item := range items {
+			if err := rb.validate(item); err != nil {
+				return err
+			}
+		}
+	}
+	rb.mu.Lock()
+	if rb.closed {
+		rb.mu.Unlock()
+		return ErrClosed
+	}
+	if rb.dedup != nil {
+		items = rb.dedupBatch(items)
+	}
+	if int(rb.cap.Load()-rb.size.Load()) < len(items) {
+		rb.mu.Unlock()
 		return ErrBufferIsFull
 	}
+	for _, item := range items {
+		rb.push(item)
+	}
+	filled := len(items) > 0 && rb.isFull()
+	flushed := rb.flushIfFull(filled)
+	rb.mu.Unlock()
 
-	rb.Push(item)
+	rb.notifyPushes(len(items), 0, filled)
+	if flushed != nil {
+		rb.flush(flushed)
+	}
+	rb.pushTee(items...)
 	return nil
 }
 
 // Pop removes and returns an element from the beginning of the buffer.
-// If the buffer is empty, returns an empty value and false.
+// If the buffer is empty, returns an empty value and false. With
+// WithShrinkPolicy, the buffer capacity may be reduced afterwards.
 func (rb *ringBuffer[T]) Pop() (T, bool) {
+	if rb.latency != nil {
+		defer rb.observeLatency(&rb.latency.pop, rb.now())
+	}
 	rb.mu.Lock()
-	defer rb.mu.Unlock()
-	if rb.size == 0 {
+	item, ok := rb.pop()
+	oldCap, newCap := rb.shrink(ok)
+	rb.mu.Unlock()
+
+	rb.notifyResize(oldCap, newCap)
+	if ok {
+		rb.notifyPops(1)
+	}
+	return item, ok
+}
+
+// PushPop adds item to the buffer and removes the oldest element under a
+// single lock, so the buffer size stays the same, and returns the removed
+// element. If the buffer is empty, only adds item and returns an empty value
+// and false. If item is rejected or skipped, e.g. by WithValidator, nothing is
+// added or removed, and an empty value and false are returned.
+func (rb *ringBuffer[T]) PushPop(item T) (popped T, ok bool) {
+	if !rb.isValid(item) {
+		return popped, false
+	}
+	rb.mu.Lock()
+	rb.panicIfClosed()
+	if rb.isDup(item) {
+		rb.mu.Unlock()
+		return popped, false
+	}
+	popped, ok = rb.pop()
+	rb.push(item)
+	filled := !ok && rb.isFull()
+	flushed := rb.flushIfFull(filled)
+	rb.mu.Unlock()
+
+	if ok {
+		rb.notifyPops(1)
+	}
+	rb.pushTee(item)
+	rb.notifyPush(false, filled)
+	if flushed != nil {
+		rb.flush(flushed)
+	}
+	return popped, ok
+}
+
+// PopC works like Pop, but also returns the number of elements remaining in
+// the buffer after the pop. All values are computed under a single lock, so
+// the remaining count is consistent with the pop, unlike a separate call to
+// Size.
+func (rb *ringBuffer[T]) PopC() (item T, ok bool, remaining int) {
+	rb.mu.Lock()
+	item, ok = rb.pop()
+	remaining = int(rb.size.Load())
+	rb.mu.Unlock()
+
+	if ok {
+		rb.notifyPops(1)
+	}
+	return item, ok, remaining
+}
+
+// PopIf removes and returns the oldest element only if pred returns true for
+// it. Otherwise, the element stays in the buffer and PopIf returns it along
+// with false. If the buffer is empty, returns an empty value and false.
+// The check and the removal are done under a single lock.
+func (rb *ringBuffer[T]) PopIf(pred func(T) bool) (T, bool) {
+	rb.mu.Lock()
+	if rb.size.Load() == 0 {
+		rb.mu.Unlock()
 		var zero T
 		return zero, false
 	}
+	if item := rb.data[rb.readerIdx]; !pred(item) {
+		rb.mu.Unlock()
+		return item, false
+	}
+	item, ok := rb.pop()
+	rb.mu.Unlock()
 
-	item := rb.data[rb.readerIdx]
-	rb.writeZeroVal(rb.readerIdx)
-	if round := rb.shiftIdx(&rb.readerIdx); round {
-		rb.wrapped = false
+	if ok {
+		rb.notifyPops(1)
 	}
-	return item, true
+	return item, ok
+}
+
+// PopWhile removes and returns the oldest elements as long as pred returns
+// true for them, ordered from the oldest to the newest. The first element for
+// which pred returns false stays in the buffer. Returns nil if no element was
+// removed.
+func (rb *ringBuffer[T]) PopWhile(pred func(T) bool) []T {
+	rb.mu.Lock()
+	var items []T
+	for rb.size.Load() > 0 && pred(rb.data[rb.readerIdx]) {
+		item, _ := rb.pop()
+		items = append(items, item)
+	}
+	rb.mu.Unlock()
+
+	rb.notifyPops(len(items))
+	return items
+}
+
+// PopNewest removes and returns the most recently pushed element, which makes
+// it possible to use the buffer as a stack. The sequence number of the removed
+// element is reissued to the next pushed one, see HeadSeq. If the buffer is
+// empty, returns an empty value and false.
+func (rb *ringBuffer[T]) PopNewest() (T, bool) {
+	rb.mu.Lock()
+	item, ok := rb.popNewest()
+	rb.mu.Unlock()
+
+	if ok {
+		rb.notifyPops(1)
+	}
+	return item, ok
+}
+
+// PopAll removes and returns all elements from the buffer, ordered from the
+// oldest to the newest. The buffer is empty afterwards.
+func (rb *ringBuffer[T]) PopAll() []T {
+	rb.mu.Lock()
+	items := make([]T, 0, rb.size.Load())
+	for {
+		item, ok := rb.pop()
+		if !ok {
+			break
+		}
+		items = append(items, item)
+	}
+	rb.mu.Unlock()
+
+	rb.notifyPops(len(items))
+	return items
+}
+
+// PopInto removes up to len(dst) oldest elements and stores them in dst,
+// ordered from the oldest to the newest. Returns the number of elements
+// removed. Unlike PopAll, it doesn't allocate memory for the result.
+func (rb *ringBuffer[T]) PopInto(dst []T) int {
+	rb.mu.Lock()
+	n := 0
+	for n < len(dst) {
+		item, ok := rb.pop()
+		if !ok {
+			break
+		}
+		dst[n] = item
+		n++
+	}
+	rb.mu.Unlock()
+
+	rb.notifyPops(n)
+	return n
+}
+
+// Discard removes up to n oldest elements without returning them. Returns the
+// number of elements actually removed, which is less than n if the buffer
+// runs out of elements.
+func (rb *ringBuffer[T]) Discard(n int) int {
+	rb.mu.Lock()
+	discarded := 0
+	for discarded < n {
+		if _, ok := rb.pop(); !ok {
+			break
+		}
+		discarded++
+	}
+	rb.mu.Unlock()
+
+	rb.notifyPops(discarded)
+	return discarded
+}
+
+// TryPop attempts to remove and return an element from the beginning of the
+// buffer. If the buffer is empty, it returns ErrBufferIsEmpty.
+func (rb *ringBuffer[T]) TryPop() (T, error) {
+	item, ok := rb.Pop()
+	if !ok {
+		return item, ErrBufferIsEmpty
+	}
+	return item, nil
+}
+
+// PopTimeout works like Pop, but if the buffer is empty, it waits up to d for
+// an element. If no element arrives in time, returns an empty value and false.
+func (rb *ringBuffer[T]) PopTimeout(d time.Duration) (T, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	if item, ok := rb.popWait(ctx); ok {
+		return item, ok
+	}
+	// The wait gives up once the deadline passes, even if the buffer isn't
+	// empty by then, so make the last attempt.
+	return rb.Pop()
+}
+
+// Channel returns a channel that receives elements popped from the buffer.
+// The elements are popped by a separate goroutine, which waits for new
+// elements when the buffer is empty. When ctx is cancelled, the goroutine
+// stops and closes the channel. An element that has been popped but not yet
+// received at that moment is discarded.
+func (rb *ringBuffer[T]) Channel(ctx context.Context) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for {
+			item, ok := rb.popWait(ctx)
+			if !ok {
+				return
+			}
+			select {
+			case ch <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Pipe pops the elements and sends them to out, waiting for new elements
+// when the buffer is empty, until ctx is cancelled or the buffer is closed and
+// drained. Unlike Channel, it runs in the calling goroutine and sends to a
+// channel owned by the caller, which it never closes. A send blocks until out
+// is ready, so a slow receiver slows down the popping, and the elements stay
+// in the buffer meanwhile. An element that has been popped but not yet sent
+// when ctx is cancelled is discarded.
+func (rb *ringBuffer[T]) Pipe(ctx context.Context, out chan<- T) {
+	for {
+		item, ok := rb.popWait(ctx)
+		if !ok {
+			return
+		}
+		select {
+		case out <- item:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Consume repeatedly pops batches of up to batch oldest elements and passes
+// them to fn, until ctx is cancelled. It waits while the buffer is empty, so
+// fn is never called with an empty batch. The batch is passed in order from
+// the oldest to the newest, and fn is called without holding the lock, so it
+// may use the buffer. If batch is less than 1, it's treated as 1.
+func (rb *ringBuffer[T]) Consume(ctx context.Context, batch int, fn func([]T)) {
+	batch = max(batch, 1)
+	for {
+		rb.mu.Lock()
+		if !rb.waitNotEmpty(ctx) {
+			rb.mu.Unlock()
+			return
+		}
+		items := make([]T, 0, min(batch, int(rb.size.Load())))
+		for len(items) < batch {
+			item, ok := rb.pop()
+			if !ok {
+				break
+			}
+			items = append(items, item)
+		}
+		rb.mu.Unlock()
+
+		rb.notifyPops(len(items))
+		fn(items)
+	}
+}
+
+// WaitUntilFull blocks until the buffer is full. If ctx is done before that,
+// returns the context error.
+func (rb *ringBuffer[T]) WaitUntilFull(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		rb.mu.Lock()
+		rb.fullCond.Broadcast()
+		rb.mu.Unlock()
+	})
+	defer stop()
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	for !rb.isFull() && !rb.closed && ctx.Err() == nil {
+		rb.fullCond.Wait()
+	}
+	if rb.isFull() {
+		return nil
+	}
+	if rb.closed {
+		return ErrClosed
+	}
+	return ctx.Err()
+}
+
+// NotEmpty returns a channel that is closed when the buffer transitions from
+// empty to not empty, e.g. after a Push. Each transition closes the current
+// channel and creates a new one, so NotEmpty must be called again to wait for
+// the next transition. The channel doesn't tell whether the buffer is empty
+// at the moment, so it's meant to be combined with TryPop in a select loop.
+func (rb *ringBuffer[T]) NotEmpty() <-chan struct{} {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	return rb.notEmpty
+}
+
+// NotFull returns a channel that is closed when the buffer transitions from
+// full to not full, e.g. after a Pop. Each transition closes the current
+// channel and creates a new one, so NotFull must be called again to wait for
+// the next transition. The channel doesn't tell whether the buffer is full
+// at the moment, so it's meant to be combined with IsFull or TryPush in a
+// select loop.
+func (rb *ringBuffer[T]) NotFull() <-chan struct{} {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	return rb.notFull
+}
+
+// Close closes the buffer for new elements and wakes up the goroutines waiting
+// for it to change. Afterwards, the push methods returning an error return
+// ErrClosed, Offer adds nothing, and the others panic, like a send on a closed
+// channel. The remaining elements can still be popped.
+func (rb *ringBuffer[T]) Close() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if rb.closed {
+		return
+	}
+	rb.closed = true
+	rb.wakeNotEmpty(true)
+	rb.fullCond.Broadcast()
+	rb.wakeNotFull()
 }
 
 // IsEmpty checks if the buffer is empty.
 func (rb *ringBuffer[T]) IsEmpty() bool {
-	return rb.Size() == 0
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	return rb.size.Load() == 0
 }
 
 // IsFull checks if the buffer is full.
 func (rb *ringBuffer[T]) IsFull() bool {
 	rb.mu.RLock()
 	defer rb.mu.RUnlock()
-	return rb.size == cap(rb.data)
+	return rb.isFull()
 }
 
 // Size returns the current size of the buffer (number of elements).
+// The size is read atomically, so no mutex is needed here.
 func (rb *ringBuffer[T]) Size() int {
-	rb.mu.RLock()
-	defer rb.mu.RUnlock()
-	return rb.size
+	return int(rb.size.Load())
 }
 
 // Capacity returns the buffer's capacity, which is the maximum number of
 // elements that the buffer can store.
-// No mutex is needed here because rb.cap is not modified concurrently.
+// The capacity is read atomically, so no mutex is needed here.
 func (rb *ringBuffer[T]) Capacity() int {
-	return rb.cap
+	return int(rb.cap.Load())
 }
 
 // Get returns an element from from the beginning of the buffer,
-// but does not remove it.
+// but does not remove it. The element is returned by value, so it's a shallow
+// copy: if T is or contains a pointer, the pointed data is shared with the
+// buffer. Use GetDeep to get an independent copy.
 func (rb *ringBuffer[T]) Get() (T, bool) {
 	rb.mu.RLock()
 	defer rb.mu.RUnlock()
-	if rb.size == 0 {
+	if rb.size.Load() == 0 {
 		var zero T
 		return zero, false
 	}
 	return rb.data[rb.readerIdx], true
 }
 
-// Clear resets the buffer to its initial state, removing all elements.
-// This operation does not modify the underlying data and is a lightweight way
-// to reuse the buffer.
-func (rb *ringBuffer[T]) Clear() {
-	if rb.IsEmpty() {
-		return
+// Ends returns the oldest and the newest elements without removing them. Both
+// are read under a single lock, so unlike Get followed by PeekNewestN, they
+// always belong to the same state of the buffer. If the buffer is empty,
+// returns empty values and false. If it holds a single element, it's returned
+// as both ends.
+func (rb *ringBuffer[T]) Ends() (oldest T, newest T, ok bool) {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	if rb.size.Load() == 0 {
+		return oldest, newest, false
 	}
-	rb.mu.Lock()
-	rb.writerIdx = 0
-	rb.readerIdx = 0
-	rb.lastWriterIdx = 0
-	rb.wrapped = false
-	rb.size = 0
-	rb.mu.Unlock()
+	return rb.data[rb.readerIdx], rb.data[rb.lastWriterIdx], true
 }
 
-// DeepClear erases all data in the buffer by writing zero values to all buffer
-// cells. This operation has a time complexity of O(n), where n is the buffer
-// size. Use this method when security or data sensitivity is a concern.
-func (rb *ringBuffer[T]) DeepClear() {
-	if rb.IsEmpty() {
-		return
+// SwapOldest replaces the oldest element with item and returns the previous
+// value. The buffer size doesn't change. If the buffer is empty or item is
+// rejected by WithValidator, returns an empty value and false without adding
+// item.
+func (rb *ringBuffer[T]) SwapOldest(item T) (old T, ok bool) {
+	if !rb.isValid(item) {
+		return old, false
 	}
 	rb.mu.Lock()
-	for i := 0; i < cap(rb.data); i++ {
-		rb.writeZeroVal(i)
+	defer rb.mu.Unlock()
+	if rb.size.Load() == 0 {
+		return old, false
 	}
-	rb.mu.Unlock()
+	old = rb.data[rb.readerIdx]
+	rb.data[rb.readerIdx] = item
+	return old, true
 }
 
-// New returns a new thread-safe ring buffer with the given capacity.
-// If the specified capacity is less than 1, returns an error.
-func New[T any](capacity int) (rb *ringBuffer[T], err error) {
-	if capacity < 1 {
-		return rb, ErrInvalidBuffCap
+// GetDeep works like Get, but returns the element copied by the given clone
+// function, which is called under the read lock. It's meant for element types
+// containing pointers, so the result can be mutated without affecting the
+// buffer.
+func (rb *ringBuffer[T]) GetDeep(clone func(T) T) (T, bool) {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	if rb.size.Load() == 0 {
+		var zero T
+		return zero, false
 	}
+	return clone(rb.data[rb.readerIdx]), true
+}
 
-	rb = &ringBuffer[T]{
-		data: make([]T, capacity),
-		cap:  capacity,
+// PeekOldestN returns up to n oldest elements without removing them. The
+// elements are ordered from the oldest to the newest. If n exceeds the buffer
+// size, all elements are returned.
+func (rb *ringBuffer[T]) PeekOldestN(n int) []T {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	n = max(0, min(n, int(rb.size.Load())))
+	return rb.copyRange(0, n)
+}
+
+// GetN returns up to n oldest elements without removing them, like
+// PeekOldestN. The elements are copied, so the buffer size and contents stay
+// unchanged.
+func (rb *ringBuffer[T]) GetN(n int) []T {
+	return rb.PeekOldestN(n)
+}
+
+// PeekNewestN returns up to n newest elements without removing them. The
+// elements are ordered from the oldest to the newest. If n exceeds the buffer
+// size, all elements are returned.
+func (rb *ringBuffer[T]) PeekNewestN(n int) []T {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	size := int(rb.size.Load())
+	n = max(0, min(n, size))
+	return rb.copyRange(size-n, n)
+}
+
+// PeekAt returns the element at the logical index i without removing it. 0
+// is the oldest element, and negative indices count from the newest one, so
+// -1 is the newest element and -Size() the oldest. If i is out of range,
+// returns an empty value and false.
+func (rb *ringBuffer[T]) PeekAt(i int) (T, bool) {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	size := int(rb.size.Load())
+	if i < 0 {
+		i += size
+	}
+	if i < 0 || i >= size {
+		var zero T
+		return zero, false
 	}
+	return rb.data[rb.physIdx(i)], true
+}
 
-	return rb, err
+// PeekFromNewest returns the k-th element from the newest one without
+// removing it, so 0 is the newest element and Size()-1 the oldest. It's the
+// same as PeekAt(-k-1), but reads better in code that reasons from the write
+// end. If k is out of range, returns an empty value and false.
+func (rb *ringBuffer[T]) PeekFromNewest(k int) (T, bool) {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	if k < 0 || k >= int(rb.size.Load()) {
+		var zero T
+		return zero, false
+	}
+	idx := rb.lastWriterIdx - k
+	if idx < 0 {
+		idx += int(rb.cap.Load())
+	}
+	return rb.data[idx], true
 }
 
-// writeZeroVal sets the element of the buffer data at the given index
-// to the zero value of T.
-func (rb *ringBuffer[T]) writeZeroVal(idx int) {
-	var zero T
-	rb.data[idx] = zero
-	if rb.size > 0 {
-		rb.size--
+// PeekRange returns up to length elements starting from the logical index
+// start (0 is the oldest element) without removing them. The range is clamped
+// to the buffer size. If start is out of range or length is not positive,
+// returns an empty slice.
+func (rb *ringBuffer[T]) PeekRange(start, length int) []T {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	size := int(rb.size.Load())
+	if start < 0 || start >= size {
+		return []T{}
 	}
+	return rb.copyRange(start, max(0, min(length, size-start)))
 }
 
-// shiftIdx advances the index to the next position in the buffer, wrapping
-// around to 0 if necessary. Returns true if the index was reset to 0,
-// false otherwise.
-func (rb *ringBuffer[T]) shiftIdx(idx *int) bool {
-	if *idx < rb.cap-1 {
-		*idx++
-		return false
-	} else {
-		*idx = 0
-		return true
+// Snapshot returns a copy of all elements without removing them, ordered
+// from the oldest to the newest.
+func (rb *ringBuffer[T]) Snapshot() []T {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	return rb.copyRange(0, int(rb.size.Load()))
+}
+
+// Iter returns an iterator over the elements, from the oldest to the newest.
+// The elements are copied under the lock when the iteration starts, and the
+// lock is released before the first element is yielded. So the iteration
+// reflects the point-in-time state of the buffer, doesn't block writers, and
+// the buffer may be modified during the iteration, even from the loop body.
+func (rb *ringBuffer[T]) Iter() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, item := range rb.Snapshot() {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// ChunkIter returns an iterator over the elements in chunks of up to chunk
+// elements, from the oldest to the newest. Each chunk is copied under the
+// lock, which is released before the chunk is yielded, so unlike Iter, it
+// bounds both the time the lock is held and the memory allocated at once.
+// The position of the next chunk is kept as a logical index, so if the buffer
+// is modified during the iteration, elements may be skipped or yielded more
+// than once. If chunk is less than 1, the elements are yielded one by one.
+func (rb *ringBuffer[T]) ChunkIter(chunk int) iter.Seq[[]T] {
+	chunk = max(chunk, 1)
+	return func(yield func([]T) bool) {
+		for start := 0; ; start += chunk {
+			rb.mu.RLock()
+			n := min(chunk, int(rb.size.Load())-start)
+			var items []T
+			if n > 0 {
+				items = rb.copyRange(start, n)
+			}
+			rb.mu.RUnlock()
+			if n <= 0 || !yield(items) {
+				return
+			}
+		}
+	}
+}
+
+// Count returns the number of elements for which pred returns true. The
+// elements are scanned under the read lock without being copied, so pred
+// must not modify the buffer.
+func (rb *ringBuffer[T]) Count(pred func(T) bool) int {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	n := 0
+	for i := 0; i < int(rb.size.Load()); i++ {
+		if pred(rb.data[rb.physIdx(i)]) {
+			n++
+		}
+	}
+	return n
+}
+
+// AppendTo appends all elements to dst, ordered from the oldest to the
+// newest, and returns the extended slice, like the built-in append. The
+// elements are not removed from the buffer.
+func (rb *ringBuffer[T]) AppendTo(dst []T) []T {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	size := int(rb.size.Load())
+	dst = slices.Grow(dst, size)
+	for i := 0; i < size; i++ {
+		dst = append(dst, rb.data[rb.physIdx(i)])
+	}
+	return dst
+}
+
+// String returns the buffer elements from the oldest to the newest along with
+// the buffer size and capacity, e.g. "[1 2 3] size=3 cap=5".
+func (rb *ringBuffer[T]) String() string {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	var sb strings.Builder
+	size := int(rb.size.Load())
+	sb.WriteByte('[')
+	for i := 0; i < size; i++ {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(fmt.Sprintf("%v", rb.data[rb.physIdx(i)]))
+	}
+	sb.WriteByte(']')
+	sb.WriteString(fmt.Sprintf(" size=%d cap=%d", size, rb.cap.Load()))
+	return sb.String()
+}
+
+// Fingerprint returns a hash of the elements in their logical order, e.g. to
+// detect changes of the buffer contents. The elements are hashed by their
+// default format, as by fmt.Sprint, so it's meant for the types whose format
+// reflects their value. Buffers with equal elements in the same order have
+// equal fingerprints, regardless of their capacity and internal layout. Use
+// FingerprintFunc to hash the elements differently.
+func (rb *ringBuffer[T]) Fingerprint() uint64 {
+	return rb.FingerprintFunc(func(item T) uint64 {
+		h := fnv.New64a()
+		fmt.Fprint(h, item)
+		return h.Sum64()
+	})
+}
+
+// FingerprintFunc works like Fingerprint, but hashes each element with the
+// given function, which is called under the read lock. The element hashes are
+// combined with the 64-bit FNV-1a hash.
+func (rb *ringBuffer[T]) FingerprintFunc(hash func(T) uint64) uint64 {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	h := fnv.New64a()
+	var buf [8]byte
+	for i := 0; i < int(rb.size.Load()); i++ {
+		binary.LittleEndian.PutUint64(buf[:], hash(rb.data[rb.physIdx(i)]))
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// FillSamples returns the buffer sizes recorded after the most recent push
+// and pop operations, ordered from the oldest to the newest. Returns nil if
+// sampling isn't enabled with WithFillSampler.
+func (rb *ringBuffer[T]) FillSamples() []int {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	if rb.fillSamples == nil {
+		return nil
+	}
+	return rb.fillSamples.copyRange(0, int(rb.fillSamples.size.Load()))
+}
+
+// StatsSnapshot returns the current size and capacity of the buffer along
+// with the number of pushed, popped and overwritten elements since its
+// creation. All values are read under a single lock, so they're consistent
+// with each other.
+func (rb *ringBuffer[T]) StatsSnapshot() Stats {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	return Stats{
+		Size:        int(rb.size.Load()),
+		Capacity:    int(rb.cap.Load()),
+		Pushed:      rb.pushed,
+		Popped:      rb.popped,
+		Overwritten: rb.overwritten,
+	}
+}
+
+// Overflowed returns the number of elements overwritten since the buffer
+// creation or the last ResetOverflowed, i.e. the data lost to overflows.
+// Unlike the Overwritten field of StatsSnapshot, it can be reset, e.g. after
+// each check of an alerting loop.
+func (rb *ringBuffer[T]) Overflowed() uint64 {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	return rb.overflowed
+}
+
+// ResetOverflowed sets the counter returned by Overflowed to 0. The lifetime
+// counters of StatsSnapshot aren't affected.
+func (rb *ringBuffer[T]) ResetOverflowed() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.overflowed = 0
+}
+
+// HeadSeq returns the sequence number of the oldest element. The elements are
+// numbered from 1 in the order they're pushed and the numbers are never
+// reissued, so they can be used to correlate the elements across producers
+// and consumers. If the buffer is empty, returns the number of the next push.
+func (rb *ringBuffer[T]) HeadSeq() uint64 {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	return rb.headSeq()
+}
+
+// Segments returns the elements as two slices of the backing array, so that
+// their concatenation gives the elements from the oldest to the newest. The
+// first slice starts at the oldest element, and the second one holds the
+// elements that wrapped around to the beginning of the array, if any.
+// The slices alias the buffer storage, so they must not be modified, and they
+// are valid only until the next change of the buffer. Use Snapshot to get a
+// copy.
+func (rb *ringBuffer[T]) Segments() (first []T, second []T) {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	// The capacity of the slices is limited, so that appending to them doesn't
+	// overwrite the buffer.
+	capacity := int(rb.cap.Load())
+	end := rb.readerIdx + int(rb.size.Load())
+	if end <= capacity {
+		return rb.data[rb.readerIdx:end:end], nil
+	}
+	wrapped := end - capacity
+	return rb.data[rb.readerIdx:capacity:capacity], rb.data[:wrapped:wrapped]
+}
+
+// Debug returns the internal state of the buffer: the reader and writer
+// indices, the size, the capacity, and whether the writer has wrapped around
+// ahead of the reader. It's intended for diagnostics only.
+func (rb *ringBuffer[T]) Debug() (readerIdx, writerIdx, size, cap int, wrapped bool) {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	return rb.readerIdx, rb.writerIdx, int(rb.size.Load()), int(rb.cap.Load()), rb.wrapped
+}
+
+// RawData returns a copy of the whole backing array in its physical order,
+// including the vacated cells, so its length equals the capacity. Use
+// Debug to locate the elements in it. It's intended for debugging only, the
+// logical order of the elements is given by Snapshot.
+func (rb *ringBuffer[T]) RawData() []T {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	return slices.Clone(rb.data[:rb.cap.Load()])
+}
+
+// Compact removes all elements for which isZero returns true, moving the
+// remaining ones together so that they keep their logical order. The vacated
+// cells are zeroed. Returns the number of removed elements.
+func (rb *ringBuffer[T]) Compact(isZero func(T) bool) int {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.numberSeqs()
+	size := int(rb.size.Load())
+	kept := 0
+	for i := 0; i < size; i++ {
+		item := rb.data[rb.physIdx(i)]
+		if isZero(item) {
+			continue
+		}
+		rb.data[rb.physIdx(kept)] = item
+		rb.seqs[rb.physIdx(kept)] = rb.seqs[rb.physIdx(i)]
+		kept++
+	}
+	if kept == size {
+		return 0
+	}
+
+	for i := kept; i < size; i++ {
+		rb.writeZeroVal(rb.physIdx(i))
+	}
+	rb.pos -= uint64(size - kept)
+	if kept == 0 {
+		rb.resetIdx()
+		return size
+	}
+	rb.writerIdx = rb.physIdx(kept)
+	rb.lastWriterIdx = rb.physIdx(kept - 1)
+	rb.wrapped = rb.readerIdx+kept >= int(rb.cap.Load()) && !rb.noWrap
+	return size - kept
+}
+
+// RotateLeft moves the n oldest elements to the end of the buffer, so that
+// they become the newest ones, keeping their order. n is taken modulo the
+// buffer size, and a negative n rotates to the right.
+func (rb *ringBuffer[T]) RotateLeft(n int) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.rotate(n)
+}
+
+// RotateRight moves the n newest elements to the beginning of the buffer, so
+// that they become the oldest ones, keeping their order. n is taken modulo the
+// buffer size, and a negative n rotates to the left.
+func (rb *ringBuffer[T]) RotateRight(n int) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.rotate(-n)
+}
+
+// Reverse reverses the order of the elements in place, so that the next Pop
+// returns the element that used to be the newest.
+func (rb *ringBuffer[T]) Reverse() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.numberSeqs()
+	rb.reverse(0, int(rb.size.Load()))
+}
+
+// Shuffle permutes the elements in place in a random order, e.g. to test the
+// consumers against unordered input. The permutation is drawn from r, so the
+// same seed of r gives the same order of the same elements.
+func (rb *ringBuffer[T]) Shuffle(r *rand.Rand) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.numberSeqs()
+	for i := int(rb.size.Load()) - 1; i > 0; i-- {
+		a, b := rb.physIdx(i), rb.physIdx(r.Intn(i+1))
+		rb.data[a], rb.data[b] = rb.data[b], rb.data[a]
+		rb.seqs[a], rb.seqs[b] = rb.seqs[b], rb.seqs[a]
+	}
+}
+
+// Clear resets the buffer to its initial state, removing all elements.
+// This operation does not modify the underlying data and is a lightweight way
+// to reuse the buffer.
+func (rb *ringBuffer[T]) Clear() {
+	if rb.IsEmpty() {
+		return
+	}
+	rb.mu.Lock()
+	rb.resetIdx()
+	rb.mu.Unlock()
+
+	rb.notifyOverruns()
+}
+
+// DeepClear erases all data in the buffer by writing zero values to all buffer
+// cells and resets the buffer to its initial state. This operation has a time
+// complexity of O(n), where n is the buffer size. Use this method when
+// security or data sensitivity is a concern.
+func (rb *ringBuffer[T]) DeepClear() {
+	// With WithZeroOnPop(false), an empty buffer may still hold the popped
+	// elements.
+	if rb.IsEmpty() && !rb.noZeroOnPop {
+		return
+	}
+	rb.mu.Lock()
+	for i := 0; i < cap(rb.data); i++ {
+		rb.writeZeroVal(i)
+	}
+	rb.resetIdx()
+	rb.mu.Unlock()
+
+	rb.notifyOverruns()
+}
+
+// ClearRefs removes all elements by writing zero values to the cells holding
+// them and resets the buffer to its initial state. Unlike DeepClear, it skips
+// the vacated cells, which are already zeroed by Pop, so it is cheaper for a
+// sparsely filled buffer while still releasing the references held by the
+// elements. With WithZeroOnPop(false), the vacated cells may keep stale
+// references, use DeepClear to release them.
+func (rb *ringBuffer[T]) ClearRefs() {
+	if rb.IsEmpty() {
+		return
+	}
+	rb.mu.Lock()
+	var zero T
+	for i := range int(rb.size.Load()) {
+		rb.data[rb.physIdx(i)] = zero
+	}
+	rb.resetIdx()
+	rb.mu.Unlock()
+
+	rb.notifyOverruns()
+}
+
+// ReplaceAll removes all elements and adds the given ones as by PushSlice, but
+// under a single lock, so concurrent readers see either the previous or the
+// new contents, never a mix of them. With WithOverflow(Block), the elements
+// that don't fit are dropped. Panics with ErrClosed if the buffer is closed.
+func (rb *ringBuffer[T]) ReplaceAll(items []T) {
+	rb.mu.Lock()
+	rb.panicIfClosed()
+	oldCap := int(rb.cap.Load())
+	rb.resetIdx()
+	var added []T
+	var flushed [][]T
+	var filled bool
+	pushed, overwrites := 0, 0
+	for _, item := range items {
+		ok, overwritten := rb.add(item)
+		if !ok {
+			continue
+		}
+		if overwritten {
+			overwrites++
+		} else if rb.isFull() {
+			filled = true
+			if items := rb.flushIfFull(true); items != nil {
+				flushed = append(flushed, items)
+			}
+		}
+		pushed++
+		if rb.tee != nil {
+			added = append(added, item)
+		}
+	}
+	newCap := int(rb.cap.Load())
+	rb.mu.Unlock()
+
+	rb.notifyResize(oldCap, newCap)
+	rb.notifyPushes(pushed, overwrites, filled)
+	for _, items := range flushed {
+		rb.flush(items)
+	}
+	rb.pushTee(added...)
+}
+
+// Warm writes zero values to the cells of the backing array not holding any
+// elements, so that the memory is touched and the operating system maps all
+// its pages in advance. The cells holding elements were already written. It's
+// an optional optimization for latency-sensitive uses, e.g. to call it at
+// startup, so that the first pushes don't cause page faults. The elements and
+// their order are not changed.
+func (rb *ringBuffer[T]) Warm() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	var zero T
+	for i := int(rb.size.Load()); i < int(rb.cap.Load()); i++ {
+		rb.data[rb.physIdx(i)] = zero
+	}
+}
+
+// Reset discards all elements and changes the buffer capacity to newCap.
+// Unlike Clear, it changes the capacity, and unlike resizing it doesn't
+// preserve any elements. If newCap fits into the existing backing array, the
+// array is reused and zeroed, otherwise a new one is allocated.
+// If newCap is less than 1, returns ErrInvalidBuffCap.
+func (rb *ringBuffer[T]) Reset(newCap int) error {
+	if newCap < 1 {
+		return ErrInvalidBuffCap
+	}
+
+	rb.mu.Lock()
+	oldCap := int(rb.cap.Load())
+	newCap = rb.roundCap(newCap)
+	if newCap <= cap(rb.data) {
+		// The whole array is zeroed, since the cells past newCap stay
+		// reachable through it and would keep their elements alive.
+		clear(rb.data[:cap(rb.data)])
+		rb.data = rb.data[:newCap:newCap]
+	} else {
+		rb.data = make([]T, newCap)
+	}
+	rb.resetIdx()
+	rb.cap.Store(int64(newCap))
+	rb.shrunkFrom = 0
+	rb.wakeFull()
+	rb.mu.Unlock()
+
+	rb.notifyResize(oldCap, newCap)
+	rb.notifyOverruns()
+	return nil
+}
+
+// Resize changes the buffer capacity to newCap, keeping the elements in their
+// order. If newCap is less than the buffer size, the oldest elements are
+// dropped as if overwritten. The whole reallocation happens under the lock, so
+// it's safe to call Resize concurrently with other operations.
+// If newCap is less than 1, returns ErrInvalidBuffCap.
+func (rb *ringBuffer[T]) Resize(newCap int) error {
+	if newCap < 1 {
+		return ErrInvalidBuffCap
+	}
+
+	rb.mu.Lock()
+	oldCap := int(rb.cap.Load())
+	dropped := rb.realloc(newCap)
+	rb.shrunkFrom = 0
+	newCap = int(rb.cap.Load())
+	rb.mu.Unlock()
+
+	rb.notifyResize(oldCap, newCap)
+	rb.notifyPushes(0, dropped, false)
+	return nil
+}
+
+// Grow increases the buffer capacity by the given number of slots, keeping
+// all elements in their order. If additional is not positive, Grow does
+// nothing.
+func (rb *ringBuffer[T]) Grow(additional int) {
+	if additional <= 0 {
+		return
+	}
+	rb.mu.Lock()
+	oldCap := int(rb.cap.Load())
+	rb.realloc(oldCap + additional)
+	rb.shrunkFrom = 0
+	newCap := int(rb.cap.Load())
+	rb.mu.Unlock()
+
+	rb.notifyResize(oldCap, newCap)
+}
+
+// Trim shrinks the buffer capacity to the number of its elements, or to 1 if
+// the buffer is empty, keeping the elements in their order. It's meant to
+// release memory after a burst. If the capacity already matches, Trim does
+// nothing.
+func (rb *ringBuffer[T]) Trim() {
+	rb.mu.Lock()
+	oldCap := int(rb.cap.Load())
+	newCap := rb.roundCap(max(int(rb.size.Load()), 1))
+	if newCap != oldCap {
+		rb.realloc(newCap)
+	}
+	rb.shrunkFrom = 0
+	rb.mu.Unlock()
+
+	rb.notifyResize(oldCap, newCap)
+}
+
+// Move pops up to n oldest elements from the buffer and pushes them to dst in
+// the same order, following the push options of dst. Moving stops when dst
+// can't take the next element, e.g. when it's full with WithNoWrap, so no
+// element is lost. Returns the number of elements moved.
+func (rb *ringBuffer[T]) Move(dst *ringBuffer[T], n int) int {
+	if dst == rb || n <= 0 {
+		return 0
+	}
+
+	first, second := orderedPair(rb, dst)
+	first.mu.Lock()
+	second.mu.Lock()
+	oldCap := int(dst.cap.Load())
+	var added []T
+	var flushed [][]T
+	moved, pushed, overwrites := 0, 0, 0
+	filled := false
+	for moved < n && rb.size.Load() > 0 && !dst.closed {
+		dst.autoGrow()
+		if (dst.noWrap || dst.block) && dst.isFull() || !dst.isValid(rb.data[rb.readerIdx]) {
+			break
+		}
+		item, _ := rb.pop()
+		moved++
+		if dst.isDup(item) {
+			continue
+		}
+		if dst.push(item) {
+			overwrites++
+		} else if dst.isFull() {
+			filled = true
+			if items := dst.flushIfFull(true); items != nil {
+				flushed = append(flushed, items)
+			}
+		}
+		pushed++
+		if dst.tee != nil {
+			added = append(added, item)
+		}
+	}
+	newCap := int(dst.cap.Load())
+	second.mu.Unlock()
+	first.mu.Unlock()
+
+	rb.notifyPops(moved)
+	dst.notifyResize(oldCap, newCap)
+	dst.notifyPushes(pushed, overwrites, filled)
+	for _, items := range flushed {
+		dst.flush(items)
+	}
+	dst.pushTee(added...)
+	return moved
+}
+
+// New returns a new thread-safe ring buffer with the given capacity,
+// configured by the given options.
+// If the specified capacity is less than 1, returns an error, unless
+// WithDefaultCapacity provides a capacity to use instead.
+func New[T any](capacity int, opts ...Option[T]) (rb *ringBuffer[T], err error) {
+	var o options[T]
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if capacity < 1 {
+		capacity = o.defaultCap
+	}
+	if capacity < 1 {
+		return rb, ErrInvalidBuffCap
+	}
+
+	rb = &ringBuffer[T]{
+		notEmpty:     make(chan struct{}),
+		notFull:      make(chan struct{}),
+		observer:     o.observer,
+		noWrap:       o.noWrap,
+		block:        o.block,
+		maxCap:       o.maxCap,
+		pow2:         o.pow2,
+		dedup:        o.dedup,
+		flush:        o.flush,
+		now:          time.Now,
+		noZeroOnPop:  o.noZeroOnPop,
+		validate:     o.validate,
+		shrinkFactor: o.shrink,
+		tee:          o.tee,
+	}
+	if o.now != nil {
+		rb.now = o.now
+	}
+	capacity = rb.roundCap(capacity)
+	rb.data = make([]T, capacity)
+	rb.cap.Store(int64(capacity))
+	rb.notEmptyCond = sync.NewCond(&rb.mu)
+	rb.fullCond = sync.NewCond(&rb.mu)
+	rb.notFullCond = sync.NewCond(&rb.mu)
+	if o.fillSamples > 0 {
+		rb.fillSamples, _ = New[int](o.fillSamples)
+	}
+	if o.latency {
+		rb.latency = &latencyRecorder{}
+	}
+	var added []T
+	for _, item := range o.initialData {
+		if ok, _ := rb.add(item); ok && rb.tee != nil {
+			added = append(added, item)
+		}
+	}
+	rb.pushTee(added...)
+
+	return rb, err
+}
+
+// MustNew is like New but panics if the buffer can't be created. It's meant
+// for package-level variables and tests, where the capacity is a constant.
+func MustNew[T any](capacity int, opts ...Option[T]) *ringBuffer[T] {
+	rb, err := New[T](capacity, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return rb
+}
+
+// Equal reports whether a and b contain the same elements in the same
+// logical order. The capacity and the internal layout of the buffers are not
+// taken into account.
+func Equal[T comparable](a, b *ringBuffer[T]) bool {
+	if a == b {
+		return true
+	}
+
+	first, second := orderedPair(a, b)
+	first.mu.RLock()
+	defer first.mu.RUnlock()
+	second.mu.RLock()
+	defer second.mu.RUnlock()
+
+	size := int(a.size.Load())
+	if int(b.size.Load()) != size {
+		return false
+	}
+	for i := 0; i < size; i++ {
+		if a.data[a.physIdx(i)] != b.data[b.physIdx(i)] {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns a new buffer with the same capacity as rb, containing only
+// the elements for which keep returns true, in their original order. The
+// source buffer is not modified.
+func Filter[T any](rb *ringBuffer[T], keep func(T) bool) *ringBuffer[T] {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	filtered, _ := New[T](int(rb.cap.Load()))
+	for i := 0; i < int(rb.size.Load()); i++ {
+		if item := rb.data[rb.physIdx(i)]; keep(item) {
+			filtered.push(item)
+		}
+	}
+	return filtered
+}
+
+// Map returns a new buffer with the same capacity as rb, containing the
+// results of applying f to each element, in their original order. The source
+// buffer is not modified.
+func Map[T, U any](rb *ringBuffer[T], f func(T) U) *ringBuffer[U] {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	mapped, _ := New[U](int(rb.cap.Load()))
+	for i := 0; i < int(rb.size.Load()); i++ {
+		mapped.push(f(rb.data[rb.physIdx(i)]))
+	}
+	return mapped
+}
+
+// IndexOf returns the logical index (0 is the oldest element) of the first
+// occurrence of target in the buffer, or -1 if target is not present.
+func IndexOf[T comparable](rb *ringBuffer[T], target T) int {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	for i := 0; i < int(rb.size.Load()); i++ {
+		if rb.data[rb.physIdx(i)] == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// Min returns the smallest element in the buffer. If the buffer is empty,
+// returns an empty value and false.
+func Min[T cmp.Ordered](rb *ringBuffer[T]) (T, bool) {
+	return extreme(rb, func(a, b T) bool { return a < b })
+}
+
+// Max returns the largest element in the buffer. If the buffer is empty,
+// returns an empty value and false.
+func Max[T cmp.Ordered](rb *ringBuffer[T]) (T, bool) {
+	return extreme(rb, func(a, b T) bool { return a > b })
+}
+
+// Reduce folds the buffer elements from the oldest to the newest into a single
+// value, starting with init and applying f to the accumulator and each
+// element.
+func Reduce[T, A any](rb *ringBuffer[T], init A, f func(A, T) A) A {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	acc := init
+	for i := 0; i < int(rb.size.Load()); i++ {
+		acc = f(acc, rb.data[rb.physIdx(i)])
+	}
+	return acc
+}
+
+// RecentDistinct returns up to n most recent distinct elements of the buffer,
+// ordered from the newest to the oldest. Each value is reported once, at the
+// position of its newest occurrence.
+func RecentDistinct[T comparable](rb *ringBuffer[T], n int) []T {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	var items []T
+	seen := make(map[T]struct{})
+	for i := int(rb.size.Load()) - 1; i >= 0 && len(items) < n; i-- {
+		item := rb.data[rb.physIdx(i)]
+		if _, ok := seen[item]; ok {
+			continue
+		}
+		seen[item] = struct{}{}
+		items = append(items, item)
+	}
+	return items
+}
+
+// DrainGroupBy removes all elements from the buffer and groups them by the
+// result of key. The elements of each group keep their order from the oldest
+// to the newest. key is called after the lock is released.
+func DrainGroupBy[T any, K comparable](rb *ringBuffer[T], key func(T) K) map[K][]T {
+	groups := make(map[K][]T)
+	for _, item := range rb.PopAll() {
+		k := key(item)
+		groups[k] = append(groups[k], item)
+	}
+	return groups
+}
+
+// extreme returns the element e for which better(e, other) holds against all
+// the other elements. If the buffer is empty, returns an empty value and false.
+func extreme[T any](rb *ringBuffer[T], better func(a, b T) bool) (T, bool) {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	size := int(rb.size.Load())
+	if size == 0 {
+		var zero T
+		return zero, false
+	}
+
+	best := rb.data[rb.readerIdx]
+	for i := 1; i < size; i++ {
+		if item := rb.data[rb.physIdx(i)]; better(item, best) {
+			best = item
+		}
+	}
+	return best, true
+}
+
+// pushItem implements Push, PushValidated and TryPush. If strict is true and
+// the buffer is full, returns ErrBufferIsFull instead of overwriting the oldest
+// element. If the buffer is closed, returns ErrClosed.
+func (rb *ringBuffer[T]) pushItem(item T, strict bool) error {
+	if rb.validate != nil {
+		if err := rb.validate(item); err != nil {
+			return err
+		}
+	}
+	rb.mu.Lock()
+	if !strict {
+		rb.waitNotFull()
+	}
+	if rb.closed {
+		rb.mu.Unlock()
+		return ErrClosed
+	}
+	if rb.isDup(item) {
+		rb.mu.Unlock()
+		return nil
+	}
+	oldCap, newCap := rb.autoGrow()
+	if rb.isFull() && (strict || rb.noWrap) {
+		rb.mu.Unlock()
+		if strict {
+			return ErrBufferIsFull
+		}
+		return nil
+	}
+	overwritten := rb.push(item)
+	filled := !overwritten && rb.isFull()
+	flushed := rb.flushIfFull(filled)
+	rb.mu.Unlock()
+
+	rb.notifyResize(oldCap, newCap)
+	rb.notifyPush(overwritten, filled)
+	if flushed != nil {
+		rb.flush(flushed)
+	}
+	rb.pushTee(item)
+	return nil
+}
+
+// push adds an element to the buffer, overwriting the oldest element if the
+// buffer is full. Reports whether an element was overwritten.
+// The caller must hold the lock.
+func (rb *ringBuffer[T]) push(item T) (overwritten bool) {
+	wasEmpty := rb.size.Load() == 0
+	overwritten = rb.isFull()
+	rb.data[rb.writerIdx] = item
+	rb.lastWriterIdx = rb.writerIdx
+	if overwritten {
+		// The oldest element was at the same slot, so the next one becomes
+		// the oldest.
+		if round := rb.shiftIdx(&rb.readerIdx); round {
+			rb.wrapped = false
+		}
+		rb.overwritten++
+		rb.overflowed++
+	} else {
+		rb.size.Add(1)
+	}
+	rb.pushed++
+	rb.seq++
+	rb.pos++
+	if rb.seqs != nil {
+		rb.seqs[rb.writerIdx] = rb.seq
+	}
+	if round := rb.shiftIdx(&rb.writerIdx); round && !rb.noWrap {
+		rb.wrapped = true
+	}
+	if overwritten {
+		rb.detectOverruns()
+	}
+	rb.sampleFill()
+	rb.wakeNotEmpty(wasEmpty)
+	rb.wakeFull()
+	return overwritten
+}
+
+// pop removes and returns the oldest element. If the buffer is empty, returns
+// an empty value and false. The caller must hold the lock.
+func (rb *ringBuffer[T]) pop() (T, bool) {
+	if rb.size.Load() == 0 {
+		var zero T
+		return zero, false
+	}
+
+	item := rb.data[rb.readerIdx]
+	rb.vacate(rb.readerIdx)
+	if round := rb.shiftIdx(&rb.readerIdx); round {
+		rb.wrapped = false
+	}
+	rb.popped++
+	rb.detectOverruns()
+	rb.sampleFill()
+	return item, true
+}
+
+// flushIfFull clears the buffer if it was filled by a push and
+// WithFlushOnFull is set. Returns the elements to pass to the flush function
+// after the lock is released, or nil if there's nothing to flush.
+// The caller must hold the lock.
+func (rb *ringBuffer[T]) flushIfFull(filled bool) []T {
+	if rb.flush == nil || !filled {
+		return nil
+	}
+	items := rb.copyRange(0, int(rb.size.Load()))
+	rb.resetIdx()
+	return items
+}
+
+// add pushes item following the push options, like Push, except that with
+// WithOverflow(Block) it drops the item instead of waiting for free space, and
+// that it doesn't flush the buffer. Reports whether the item was added and
+// whether it overwrote the oldest element. The caller must hold the lock.
+func (rb *ringBuffer[T]) add(item T) (added, overwritten bool) {
+	if !rb.isValid(item) || rb.isDup(item) {
+		return false, false
+	}
+	rb.autoGrow()
+	if rb.isFull() && (rb.noWrap || rb.block) {
+		return false, false
+	}
+	return true, rb.push(item)
+}
+
+// isValid reports whether the item passes the validator set by
+// WithValidator, if any. It may be called with the lock held.
+func (rb *ringBuffer[T]) isValid(item T) bool {
+	return rb.validate == nil || rb.validate(item) == nil
+}
+
+// isDup reports whether the item must be skipped as a duplicate of the newest
+// element, as configured by WithDedup. The caller must hold the lock.
+func (rb *ringBuffer[T]) isDup(item T) bool {
+	return rb.dedup != nil && rb.size.Load() > 0 && rb.dedup(rb.data[rb.lastWriterIdx], item)
+}
+
+// dedupBatch returns the items that are left once the ones WithDedup skips are
+// removed, comparing each item with the newest element or the previous kept
+// item. The caller must hold the lock.
+func (rb *ringBuffer[T]) dedupBatch(items []T) []T {
+	kept := make([]T, 0, len(items))
+	for _, item := range items {
+		if len(kept) > 0 && rb.dedup(kept[len(kept)-1], item) || len(kept) == 0 && rb.isDup(item) {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+// isFull reports whether the buffer is full. The caller must hold the lock.
+func (rb *ringBuffer[T]) isFull() bool {
+	return rb.size.Load() == rb.cap.Load()
+}
+
+// resetIdx resets the buffer indices and size to their initial state.
+// The caller must hold the lock.
+func (rb *ringBuffer[T]) resetIdx() {
+	if rb.isFull() {
+		rb.wakeNotFull()
+	}
+	rb.writerIdx = 0
+	rb.readerIdx = 0
+	rb.lastWriterIdx = 0
+	rb.wrapped = false
+	rb.size.Store(0)
+	rb.seqs = nil
+	rb.detectOverruns()
+}
+
+// headSeq returns the sequence number of the oldest element, see HeadSeq.
+// The caller must hold the lock.
+func (rb *ringBuffer[T]) headSeq() uint64 {
+	if rb.seqs == nil || rb.size.Load() == 0 {
+		return rb.seq - uint64(rb.size.Load()) + 1
+	}
+	return rb.seqs[rb.readerIdx]
+}
+
+// numberSeqs records the sequence numbers of the elements in seqs, unless
+// they're already recorded, so that they can be moved along with the
+// elements. The caller must hold the lock.
+func (rb *ringBuffer[T]) numberSeqs() {
+	if rb.seqs != nil {
+		return
+	}
+	head := rb.headSeq()
+	rb.seqs = make([]uint64, rb.cap.Load())
+	for i := range int(rb.size.Load()) {
+		rb.seqs[rb.physIdx(i)] = head + uint64(i)
+	}
+}
+
+// headPos returns the position of the oldest element in the stream followed
+// by the subscriptions. The caller must hold the lock.
+func (rb *ringBuffer[T]) headPos() uint64 {
+	return rb.pos - uint64(rb.size.Load()) + 1
+}
+
+// waitNotFull blocks while the buffer is full if it was created
+// WithOverflow(Block). It stops waiting if the buffer gets closed. The caller
+// must hold the lock.
+func (rb *ringBuffer[T]) waitNotFull() {
+	for rb.block && rb.isFull() && !rb.closed {
+		rb.notFullCond.Wait()
+	}
+}
+
+// panicIfClosed panics with ErrClosed if the buffer is closed, releasing the
+// lock first. The caller must hold the lock.
+func (rb *ringBuffer[T]) panicIfClosed() {
+	if rb.closed {
+		rb.mu.Unlock()
+		panic(ErrClosed)
+	}
+}
+
+// popWait removes and returns the oldest element, waiting until the buffer
+// is not empty. If ctx is done before an element is available, returns an
+// empty value and false.
+func (rb *ringBuffer[T]) popWait(ctx context.Context) (T, bool) {
+	rb.mu.Lock()
+	if !rb.waitNotEmpty(ctx) {
+		rb.mu.Unlock()
+		var zero T
+		return zero, false
+	}
+	item, ok := rb.pop()
+	rb.mu.Unlock()
+
+	if ok {
+		rb.notifyPops(1)
+	}
+	return item, ok
+}
+
+// waitNotEmpty blocks until the buffer is not empty or ctx is done. Reports
+// whether the buffer is not empty. The caller must hold the lock.
+func (rb *ringBuffer[T]) waitNotEmpty(ctx context.Context) bool {
+	ok := rb.waitPush(ctx, func() bool { return rb.size.Load() > 0 || rb.closed })
+	return ok && rb.size.Load() > 0
+}
+
+// waitPush blocks until ready returns true or ctx is done, checking ready
+// again after each push. Reports whether ctx is not done. The caller must
+// hold the lock.
+func (rb *ringBuffer[T]) waitPush(ctx context.Context, ready func() bool) bool {
+	stop := context.AfterFunc(ctx, func() {
+		rb.mu.Lock()
+		rb.notEmptyCond.Broadcast()
+		rb.mu.Unlock()
+	})
+	defer stop()
+
+	for !ready() && ctx.Err() == nil {
+		rb.notEmptyCond.Wait()
+	}
+	return ctx.Err() == nil
+}
+
+// popNewest removes and returns the newest element, moving the writer index
+// back. If the buffer is empty, returns an empty value and false.
+// The caller must hold the lock.
+func (rb *ringBuffer[T]) popNewest() (T, bool) {
+	if rb.size.Load() == 0 {
+		var zero T
+		return zero, false
+	}
+	item := rb.removeNewest()
+	rb.popped++
+	rb.sampleFill()
+	return item, true
+}
+
+// removeNewest removes and returns the newest element of a non-empty buffer,
+// moving the writer index back. The caller must hold the lock.
+func (rb *ringBuffer[T]) removeNewest() T {
+	rb.numberSeqs()
+	rb.pos--
+	// The next pushed element takes over the position, so the subscriptions
+	// that have read the removed element must read the new one.
+	for s := range rb.subs {
+		s.next = min(s.next, rb.pos+1)
+	}
+	item := rb.data[rb.lastWriterIdx]
+	rb.vacate(rb.lastWriterIdx)
+	if round := rb.unshiftIdx(&rb.writerIdx); round {
+		rb.wrapped = false
+	}
+	rb.lastWriterIdx = rb.writerIdx
+	rb.unshiftIdx(&rb.lastWriterIdx)
+	return item
+}
+
+// notifyPush notifies the observer, if any, that an element was added.
+func (rb *ringBuffer[T]) notifyPush(overwritten, filled bool) {
+	if rb.observer == nil {
+		return
+	}
+	rb.observer.OnPush()
+	if overwritten {
+		rb.observer.OnOverwrite()
+	}
+	if filled {
+		rb.observer.OnFull()
+	}
+	rb.notifyOverruns()
+}
+
+// notifyPushes notifies the observer, if any, that n elements were added, of
+// which overwrites overwrote the oldest elements, and whether the buffer was
+// filled.
+func (rb *ringBuffer[T]) notifyPushes(n, overwrites int, filled bool) {
+	if rb.observer == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		rb.observer.OnPush()
+	}
+	for i := 0; i < overwrites; i++ {
+		rb.observer.OnOverwrite()
+	}
+	if filled {
+		rb.observer.OnFull()
+	}
+	rb.notifyOverruns()
+}
+
+// notifyResize notifies the observer, if it implements ResizeObserver, that
+// the buffer capacity changed. Does nothing if the capacities are equal.
+func (rb *ringBuffer[T]) notifyResize(oldCap, newCap int) {
+	if oldCap == newCap {
+		return
+	}
+	if o, ok := rb.observer.(ResizeObserver); ok {
+		o.OnResize(oldCap, newCap)
+	}
+}
+
+// notifyPops notifies the observer, if any, that n elements were removed.
+func (rb *ringBuffer[T]) notifyPops(n int) {
+	if rb.observer == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		rb.observer.OnPop()
+	}
+	rb.notifyOverruns()
+}
+
+// pushTee pushes the elements added to the buffer into the buffer set by
+// WithTee, if any, as by PushSlice. The buffer has already accepted the
+// elements, so if the tee is closed, they're dropped instead of panicking.
+func (rb *ringBuffer[T]) pushTee(items ...T) {
+	if rb.tee == nil || len(items) == 0 {
+		return
+	}
+	rb.tee.pushSlice(items)
+}
+
+// writeZeroVal sets the element of the buffer data at the given index
+// to the zero value of T.
+func (rb *ringBuffer[T]) writeZeroVal(idx int) {
+	var zero T
+	rb.data[idx] = zero
+	if rb.size.Load() > 0 {
+		if rb.isFull() {
+			rb.wakeNotFull()
+		}
+		rb.size.Add(-1)
+	}
+}
+
+// vacate removes the element at the given index from the buffer, zeroing the
+// cell unless the buffer was created WithZeroOnPop(false).
+func (rb *ringBuffer[T]) vacate(idx int) {
+	if !rb.noZeroOnPop {
+		rb.writeZeroVal(idx)
+		return
+	}
+	if rb.size.Load() > 0 {
+		if rb.isFull() {
+			rb.wakeNotFull()
+		}
+		rb.size.Add(-1)
+	}
+}
+
+// sampleFill records the current buffer size, if fill sampling is enabled.
+// The caller must hold the lock.
+func (rb *ringBuffer[T]) sampleFill() {
+	if rb.fillSamples == nil {
+		return
+	}
+	// The samples are guarded by rb.mu, so the own lock of the samples
+	// buffer isn't used.
+	if rb.fillSamples.isFull() {
+		rb.fillSamples.pop()
+	}
+	rb.fillSamples.push(int(rb.size.Load()))
+}
+
+// wakeNotEmpty wakes up the goroutines waiting for an element. It must be
+// called after an element is added, wasEmpty telling whether the buffer was
+// empty before. The caller must hold the lock.
+func (rb *ringBuffer[T]) wakeNotEmpty(wasEmpty bool) {
+	rb.notEmptyCond.Broadcast()
+	if wasEmpty {
+		close(rb.notEmpty)
+		rb.notEmpty = make(chan struct{})
+	}
+}
+
+// wakeFull wakes up the goroutines waiting in WaitUntilFull if the buffer is
+// full. The caller must hold the lock.
+func (rb *ringBuffer[T]) wakeFull() {
+	if rb.isFull() {
+		rb.fullCond.Broadcast()
+	}
+}
+
+// wakeNotFull wakes up the goroutines waiting for free space and replaces the
+// channel returned by NotFull after closing it. It must be called when the
+// buffer stops being full. The caller must hold the lock.
+func (rb *ringBuffer[T]) wakeNotFull() {
+	rb.notFullCond.Broadcast()
+	close(rb.notFull)
+	rb.notFull = make(chan struct{})
+}
+
+// shiftIdx advances the index to the next position in the buffer, wrapping
+// around to 0 if necessary. Returns true if the index was reset to 0,
+// false otherwise.
+func (rb *ringBuffer[T]) shiftIdx(idx *int) bool {
+	if rb.pow2 {
+		*idx = (*idx + 1) & (int(rb.cap.Load()) - 1)
+		return *idx == 0
+	}
+	if *idx < int(rb.cap.Load())-1 {
+		*idx++
+		return false
+	} else {
+		*idx = 0
+		return true
+	}
+}
+
+// unshiftIdx moves the index to the previous position in the buffer,
+// wrapping around to the last position if necessary. Returns true if the index
+// was wrapped around, false otherwise.
+func (rb *ringBuffer[T]) unshiftIdx(idx *int) bool {
+	if *idx > 0 {
+		*idx--
+		return false
+	}
+	*idx = int(rb.cap.Load()) - 1
+	return true
+}
+
+// physIdx converts the logical index of an element (0 is the oldest) to its
+// index in the buffer data.
+func (rb *ringBuffer[T]) physIdx(i int) int {
+	if rb.pow2 {
+		return (rb.readerIdx + i) & (int(rb.cap.Load()) - 1)
+	}
+	return (rb.readerIdx + i) % int(rb.cap.Load())
+}
+
+// rotate rotates the elements to the left by n positions in place by
+// reversing both parts and then the whole range. The caller must hold the
+// lock.
+func (rb *ringBuffer[T]) rotate(n int) {
+	size := int(rb.size.Load())
+	if size == 0 {
+		return
+	}
+	n = (n%size + size) % size
+	if n == 0 {
+		return
+	}
+	rb.numberSeqs()
+	rb.reverse(0, n)
+	rb.reverse(n, size)
+	rb.reverse(0, size)
+}
+
+// reverse reverses the order of the elements with the logical indices from
+// start to end, exclusive. The caller must hold the lock.
+func (rb *ringBuffer[T]) reverse(start, end int) {
+	for i, j := start, end-1; i < j; i, j = i+1, j-1 {
+		pi, pj := rb.physIdx(i), rb.physIdx(j)
+		rb.data[pi], rb.data[pj] = rb.data[pj], rb.data[pi]
+		if rb.seqs != nil {
+			rb.seqs[pi], rb.seqs[pj] = rb.seqs[pj], rb.seqs[pi]
+		}
+	}
+}
+
+// copyRange returns a copy of n elements starting from the logical index
+// start. The caller must hold the lock.
+func (rb *ringBuffer[T]) copyRange(start, n int) []T {
+	items := make([]T, n)
+	for i := range items {
+		items[i] = rb.data[rb.physIdx(start+i)]
+	}
+	return items
+}
+
+// orderedPair returns the given buffers sorted by their addresses. Locks on
+// two buffers must be acquired in this order to avoid a deadlock.
+func orderedPair[T any](a, b *ringBuffer[T]) (first, second *ringBuffer[T]) {
+	if uintptr(unsafe.Pointer(a)) < uintptr(unsafe.Pointer(b)) {
+		return a, b
+	}
+	return b, a
+}
+
+// autoGrow doubles the capacity of a full buffer, limited by the maximum
+// capacity set by WithAutoGrow or the one before the shrink policy shrank it.
+// Returns the capacities before and after the growth, which are equal if the
+// buffer didn't grow. The caller must hold the lock.
+func (rb *ringBuffer[T]) autoGrow() (oldCap, newCap int) {
+	oldCap = int(rb.cap.Load())
+	limit := max(rb.maxCap, rb.shrunkFrom)
+	if rb.isFull() && oldCap < limit {
+		rb.realloc(min(2*oldCap, limit))
+		if int(rb.cap.Load()) >= rb.shrunkFrom {
+			rb.shrunkFrom = 0
+		}
+	}
+	return oldCap, int(rb.cap.Load())
+}
+
+// shrinkPatience is the number of consecutive pops that must leave the buffer
+// below the load factor set by WithShrinkPolicy before it shrinks.
+const shrinkPatience = 16
+
+// shrink halves the capacity if the pops have kept the buffer below the load
+// factor set by WithShrinkPolicy long enough. popped tells whether an element
+// was just popped. Returns the capacities before and after shrinking, which
+// are equal if the buffer didn't shrink. The caller must hold the lock.
+func (rb *ringBuffer[T]) shrink(popped bool) (oldCap, newCap int) {
+	oldCap = int(rb.cap.Load())
+	if rb.shrinkFactor <= 0 || !popped {
+		return oldCap, oldCap
+	}
+	size := int(rb.size.Load())
+	if float64(size) >= rb.shrinkFactor*float64(oldCap) {
+		rb.lowLoadPops = 0
+		return oldCap, oldCap
+	}
+	if rb.lowLoadPops++; rb.lowLoadPops < shrinkPatience {
+		return oldCap, oldCap
+	}
+	rb.lowLoadPops = 0
+	if newCap = rb.roundCap(max(oldCap/2, size, 1)); newCap < oldCap {
+		rb.realloc(newCap)
+		rb.shrunkFrom = max(rb.shrunkFrom, oldCap)
+	}
+	return oldCap, int(rb.cap.Load())
+}
+
+// roundCap rounds the capacity up to the next power of two if the buffer was
+// created WithPow2Capacity, otherwise returns it unchanged.
+func (rb *ringBuffer[T]) roundCap(capacity int) int {
+	if !rb.pow2 {
+		return capacity
+	}
+	return 1 << bits.Len(uint(capacity-1))
+}
+
+// realloc moves the elements to a new backing array of the given capacity,
+// keeping their logical order. If the new capacity is less than the buffer
+// size, the oldest elements are dropped and counted as overwritten. Returns
+// the number of dropped elements. The caller must hold the lock.
+func (rb *ringBuffer[T]) realloc(newCap int) (dropped int) {
+	newCap = rb.roundCap(newCap)
+	if rb.isFull() && newCap > int(rb.cap.Load()) {
+		rb.wakeNotFull()
+	}
+	size := int(rb.size.Load())
+	n := min(size, newCap)
+	data := make([]T, newCap)
+	for i := 0; i < n; i++ {
+		data[i] = rb.data[rb.physIdx(size-n+i)]
+	}
+	if rb.seqs != nil {
+		seqs := make([]uint64, newCap)
+		for i := 0; i < n; i++ {
+			seqs[i] = rb.seqs[rb.physIdx(size-n+i)]
+		}
+		rb.seqs = seqs
+	}
+
+	rb.data = data
+	rb.cap.Store(int64(newCap))
+	rb.size.Store(int64(n))
+	rb.readerIdx = 0
+	rb.writerIdx = n % newCap
+	rb.lastWriterIdx = max(n-1, 0)
+	rb.wrapped = n == newCap && !rb.noWrap
+	rb.wakeFull()
+	if dropped = size - n; dropped > 0 {
+		rb.overwritten += uint64(dropped)
+		rb.overflowed += uint64(dropped)
+		rb.detectOverruns()
 	}
+	return dropped
 }
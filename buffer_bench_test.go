@@ -19,6 +19,31 @@ func BenchmarkRingBufferPush(b *testing.B) {
 	}
 }
 
+// BenchmarkRingBufferPushPow2 compares wrapping the indices around with a
+// modulo and with a bitmask, as enabled by WithPow2Capacity.
+func BenchmarkRingBufferPushPow2(b *testing.B) {
+	bufCapacity := 2048
+
+	for _, pow2 := range []bool{false, true} {
+		b.Run(fmt.Sprintf("pow2: %t", pow2), func(b *testing.B) {
+			var opts []Option[int]
+			if pow2 {
+				opts = append(opts, WithPow2Capacity[int]())
+			}
+			buffer, err := New(bufCapacity, opts...)
+			if err != nil {
+				b.Error(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buffer.Push(i)
+				buffer.Pop()
+			}
+		})
+	}
+}
+
 func BenchmarkRingBufferPushConcurrent(b *testing.B) {
 	bufCapacity := 2048
 	buffer, err := New[int](bufCapacity)
@@ -60,6 +85,25 @@ func BenchmarkRingBufferPop(b *testing.B) {
 	}
 }
 
+func BenchmarkRingBufferPopZeroing(b *testing.B) {
+	bufCapacity := 2048
+
+	for _, zero := range []bool{true, false} {
+		b.Run(fmt.Sprintf("zero: %t", zero), func(b *testing.B) {
+			buffer, err := New(bufCapacity, WithZeroOnPop[[8]int](zero))
+			if err != nil {
+				b.Error(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buffer.Push([8]int{i})
+				buffer.Pop()
+			}
+		})
+	}
+}
+
 func BenchmarkRingBufferPopConcurrent(b *testing.B) {
 	bufCapacity := 2048
 	buffer, err := New[int](bufCapacity)
@@ -91,6 +135,45 @@ func BenchmarkRingBufferPopConcurrent(b *testing.B) {
 	wg.Wait()
 }
 
+func BenchmarkRingBufferSizeConcurrent(b *testing.B) {
+	bufCapacity := 2048
+	buffer, err := New[int](bufCapacity)
+	if err != nil {
+		b.Error(err)
+	}
+
+	// Keep a writer busy, so Size competes with Push for the buffer.
+	done := make(chan struct{})
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				buffer.Push(i)
+			}
+		}
+	}()
+	defer close(done)
+
+	gorAmount := 100
+	var wg sync.WaitGroup
+	wg.Add(gorAmount)
+
+	b.ResetTimer()
+	for i := 0; i < gorAmount; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < b.N; j++ {
+				buffer.Size()
+				buffer.Capacity()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
 func BenchmarkRingBufferClear(b *testing.B) {
 	var testItem = struct{}{}
 	testCases := []struct {
@@ -1,23 +1,32 @@
 package buffer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
 	"reflect"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestRingBufferImplementsInterface(t *testing.T) {
-	buffer, _ := New[string](1)
+	buffer, _ := New[string](3)
 	checkInterfaceImplementation := func(rb interface{}) bool {
 		_, ok := rb.(RingBuffer[string])
 		return ok
 	}
 	if !checkInterfaceImplementation(buffer) {
-		t.Errorf("ringBuffer does not implement RingBuffer interface")
+		t.Fatalf("ringBuffer does not implement RingBuffer interface")
+	}
+
+	// The capacity must be available without asserting the concrete type.
+	var rb RingBuffer[string] = buffer
+	if got := rb.Capacity(); got != 3 {
+		t.Errorf("buffer capacity: want 3, got %d", got)
 	}
 }
 
@@ -97,6 +106,166 @@ func TestRingBufferPushInt(t *testing.T) {
 	}
 }
 
+func TestRingBufferPushFront(t *testing.T) {
+	testCases := []struct {
+		name      string
+		bufCap    int
+		popCount  int
+		initItems []int
+		pushFront []int
+		wantItems []int
+	}{
+		{
+			name:      "empty buffer",
+			bufCap:    3,
+			pushFront: []int{1, 2},
+			wantItems: []int{2, 1},
+		},
+		{
+			name:      "reader wraps around",
+			bufCap:    4,
+			initItems: []int{1, 2},
+			pushFront: []int{3, 4},
+			wantItems: []int{4, 3, 1, 2},
+		},
+		{
+			name:      "reader in the middle",
+			bufCap:    5,
+			popCount:  3,
+			initItems: []int{1, 2, 3, 4, 5, 6},
+			pushFront: []int{7, 8},
+			wantItems: []int{8, 7, 4, 5, 6},
+		},
+		{
+			name:      "evicts newest",
+			bufCap:    3,
+			initItems: []int{1, 2, 3},
+			pushFront: []int{4, 5},
+			wantItems: []int{5, 4, 1},
+		},
+		{
+			name:      "capacity 1",
+			bufCap:    1,
+			initItems: []int{1},
+			pushFront: []int{2},
+			wantItems: []int{2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, tc.bufCap, tc.popCount, tc.initItems...)
+			for _, item := range tc.pushFront {
+				buffer.PushFront(item)
+			}
+
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItems, got)
+			}
+
+			// Pushing to the back must continue after the newest element, and
+			// removing from both ends must agree with the contents.
+			want := tc.wantItems
+			if buffer.IsFull() {
+				buffer.PopNewest()
+				want = want[:len(want)-1]
+			}
+			buffer.Push(100)
+			want = append(want, 100)
+			if got, _ := buffer.PopNewest(); got != 100 {
+				t.Errorf("PopNewest() item: want 100, got %d", got)
+			}
+			for _, item := range want[:len(want)-1] {
+				if got, _ := buffer.Pop(); got != item {
+					t.Errorf("Pop() item: want %d, got %d", item, got)
+				}
+			}
+			if !buffer.IsEmpty() {
+				t.Errorf("empty buffer expected, got size: %d", buffer.Size())
+			}
+		})
+	}
+}
+
+func TestRingBufferPushSlice(t *testing.T) {
+	testCases := []struct {
+		name        string
+		bufCap      int
+		initItems   []int
+		pushItems   []int
+		wantEvicted []int
+		wantItems   []int
+	}{
+		{
+			name:        "partially full, fits",
+			bufCap:      5,
+			initItems:   []int{1, 2},
+			pushItems:   []int{3, 4, 5},
+			wantEvicted: []int{},
+			wantItems:   []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:        "empty slice",
+			bufCap:      2,
+			initItems:   []int{1, 2},
+			pushItems:   []int{},
+			wantEvicted: []int{},
+			wantItems:   []int{1, 2},
+		},
+		{
+			name:        "past capacity",
+			bufCap:      4,
+			initItems:   []int{1, 2, 3},
+			pushItems:   []int{4, 5, 6},
+			wantEvicted: []int{1, 2},
+			wantItems:   []int{3, 4, 5, 6},
+		},
+		{
+			name:        "full buffer",
+			bufCap:      3,
+			initItems:   []int{1, 2, 3},
+			pushItems:   []int{4},
+			wantEvicted: []int{1},
+			wantItems:   []int{2, 3, 4},
+		},
+		{
+			name:        "more than capacity",
+			bufCap:      2,
+			initItems:   []int{1},
+			pushItems:   []int{2, 3, 4, 5},
+			wantEvicted: []int{1, 2, 3},
+			wantItems:   []int{4, 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := New[int](tc.bufCap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, item := range tc.initItems {
+				buffer.Push(item)
+			}
+
+			evicted := buffer.PushSlice(tc.pushItems)
+			if len(evicted) != len(tc.wantEvicted) || (len(evicted) > 0 && !reflect.DeepEqual(evicted, tc.wantEvicted)) {
+				t.Errorf("evicted items: want %v, got %v", tc.wantEvicted, evicted)
+			}
+			// The live elements are the last Size() writes preceding the
+			// writer index.
+			var got []int
+			for i := 0; i < buffer.Size(); i++ {
+				idx := (buffer.writerIdx - buffer.Size() + i + 2*tc.bufCap) % tc.bufCap
+				got = append(got, buffer.data[idx])
+			}
+			if !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItems, got)
+			}
+		})
+	}
+}
+
 func TestRingBufferTryPushInt(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -135,7 +304,7 @@ func TestRingBufferTryPushInt(t *testing.T) {
 			if len(expectedItems) > tc.bufCapacity {
 				expectedItems = expectedItems[len(expectedItems)-tc.bufCapacity:]
 			}
-			if !reflect.DeepEqual(buffer.data[:buffer.size], expectedItems) {
+			if !reflect.DeepEqual(buffer.data[:buffer.Size()], expectedItems) {
 				t.Errorf("buffer data does not match test items")
 			}
 
@@ -156,6 +325,102 @@ func TestRingBufferTryPushInt(t *testing.T) {
 	}
 }
 
+func TestRingBufferPushTimeout(t *testing.T) {
+	t.Run("space freed in time", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 2, 0, 1, 2)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			buffer.Pop()
+		}()
+		if err := buffer.PushTimeout(3, time.Second); err != nil {
+			t.Errorf("didn't expect an error: %v", err)
+		}
+		if want := []int{2, 3}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 2, 0, 1, 2)
+		start := time.Now()
+		if err := buffer.PushTimeout(3, 20*time.Millisecond); !errors.Is(err, ErrBufferIsFull) {
+			t.Errorf("want error: %v, got error: %v", ErrBufferIsFull, err)
+		}
+		if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+			t.Errorf("PushTimeout returned after %v, before the timeout", elapsed)
+		}
+		if want := []int{1, 2}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+		}
+	})
+
+	t.Run("free space", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 2, 0, 1)
+		if err := buffer.PushTimeout(2, 0); err != nil {
+			t.Errorf("didn't expect an error: %v", err)
+		}
+	})
+}
+
+func TestRingBufferOffer(t *testing.T) {
+	testCases := []struct {
+		name      string
+		initItems []int
+		items     []int
+		want      int
+		wantItems []int
+	}{
+		{name: "ample room", initItems: []int{1}, items: []int{2, 3}, want: 2, wantItems: []int{1, 2, 3}},
+		{name: "partial room", initItems: []int{1, 2}, items: []int{3, 4, 5}, want: 2, wantItems: []int{1, 2, 3, 4}},
+		{name: "exact room", initItems: []int{1, 2}, items: []int{3, 4}, want: 2, wantItems: []int{1, 2, 3, 4}},
+		{name: "no room", initItems: []int{1, 2, 3, 4}, items: []int{5, 6}, want: 0, wantItems: []int{1, 2, 3, 4}},
+		{name: "no items", initItems: []int{1}, items: nil, want: 0, wantItems: []int{1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, 4, 0, tc.initItems...)
+
+			got := buffer.Offer(tc.items)
+			if got != tc.want {
+				t.Errorf("accepted items: want %d, got %d", tc.want, got)
+			}
+			if items := buffer.Snapshot(); !reflect.DeepEqual(items, tc.wantItems) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItems, items)
+			}
+		})
+	}
+}
+
+func TestRingBufferTryPushBatch(t *testing.T) {
+	testCases := []struct {
+		name      string
+		initItems []int
+		items     []int
+		wantErr   error
+		wantItems []int
+	}{
+		{name: "ample room", initItems: []int{1}, items: []int{2, 3}, wantItems: []int{1, 2, 3}},
+		{name: "exact room", initItems: []int{1, 2}, items: []int{3, 4}, wantItems: []int{1, 2, 3, 4}},
+		{name: "partial room", initItems: []int{1, 2}, items: []int{3, 4, 5}, wantErr: ErrBufferIsFull, wantItems: []int{1, 2}},
+		{name: "no room", initItems: []int{1, 2, 3, 4}, items: []int{5}, wantErr: ErrBufferIsFull, wantItems: []int{1, 2, 3, 4}},
+		{name: "no items", initItems: []int{1, 2, 3, 4}, items: nil, wantItems: []int{1, 2, 3, 4}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, 4, 0, tc.initItems...)
+
+			if err := buffer.TryPushBatch(tc.items); !errors.Is(err, tc.wantErr) {
+				t.Errorf("want error: %v, got error: %v", tc.wantErr, err)
+			}
+			if items := buffer.Snapshot(); !reflect.DeepEqual(items, tc.wantItems) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItems, items)
+			}
+		})
+	}
+}
+
 func TestRingBufferPopString(t *testing.T) {
 	testCases := []struct {
 		bufCapacity int
@@ -175,7 +440,7 @@ func TestRingBufferPopString(t *testing.T) {
 		{
 			bufCapacity: 3,
 			testItems:   []string{"apple", "banana", "orange", "pork", "tomato"},
-			wantItems:   []string{"pork", "tomato", "orange"},
+			wantItems:   []string{"orange", "pork", "tomato"},
 		},
 		{
 			bufCapacity: 2,
@@ -232,6 +497,42 @@ func TestRingBufferPopString(t *testing.T) {
 	}
 }
 
+func TestRingBufferPushOverwrite(t *testing.T) {
+	testCases := []struct {
+		name      string
+		bufCap    int
+		popCount  int
+		items     []int
+		wantItems []int
+	}{
+		{name: "one overwrite", bufCap: 3, items: []int{1, 2, 3, 4}, wantItems: []int{2, 3, 4}},
+		{name: "full round", bufCap: 3, items: []int{1, 2, 3, 4, 5, 6}, wantItems: []int{4, 5, 6}},
+		{name: "more than a round", bufCap: 3, items: []int{1, 2, 3, 4, 5, 6, 7, 8}, wantItems: []int{6, 7, 8}},
+		{name: "wrapped buffer", bufCap: 4, popCount: 2, items: []int{1, 2, 3, 4, 5, 6, 7}, wantItems: []int{4, 5, 6, 7}},
+		{name: "single slot", bufCap: 1, items: []int{1, 2}, wantItems: []int{2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, tc.bufCap, tc.popCount, tc.items...)
+			if buffer.Size() != len(tc.wantItems) {
+				t.Errorf("buffer size: want %d, got %d", len(tc.wantItems), buffer.Size())
+			}
+			if got, _ := buffer.Get(); got != tc.wantItems[0] {
+				t.Errorf("Get(): want %d, got %d", tc.wantItems[0], got)
+			}
+			for _, want := range tc.wantItems {
+				if got, ok := buffer.Pop(); !ok || got != want {
+					t.Errorf("Pop(): want %d, true, got %d, %t", want, got, ok)
+				}
+			}
+			if !buffer.IsEmpty() {
+				t.Errorf("empty buffer expected, got size: %d", buffer.Size())
+			}
+		})
+	}
+}
+
 func TestRingBufferPopFromEmptyBuffer(t *testing.T) {
 	buffer, err := New[int](2)
 	if err != nil {
@@ -248,496 +549,3334 @@ func TestRingBufferPopFromEmptyBuffer(t *testing.T) {
 	}
 }
 
-func TestRingBufferIsEmpty(t *testing.T) {
-	testCases := []struct {
-		bufCapacity int
-		itemsAmount int
-		want        bool
-	}{
-		{bufCapacity: 1, itemsAmount: 0, want: true},
-		{bufCapacity: 1, itemsAmount: 1, want: false},
-		{bufCapacity: 5, itemsAmount: 2, want: false},
-		{bufCapacity: 3, itemsAmount: 8, want: false},
-		{bufCapacity: 5, itemsAmount: 0, want: true},
-		{bufCapacity: 5, itemsAmount: 1, want: false},
-		{bufCapacity: 5, itemsAmount: 6, want: false},
+func TestRingBufferTryPop(t *testing.T) {
+	buffer, err := New[int](3)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	for _, tc := range testCases {
-		name := fmt.Sprintf("items: %d, cap: %d", tc.bufCapacity, tc.itemsAmount)
-		t.Run(name, func(t *testing.T) {
-			buffer, err := New[int](tc.bufCapacity)
+	t.Run("empty buffer", func(t *testing.T) {
+		got, err := buffer.TryPop()
+		if !errors.Is(err, ErrBufferIsEmpty) {
+			t.Errorf("expected err: %v, got err: %v", ErrBufferIsEmpty, err)
+		}
+		if got != 0 {
+			t.Errorf("want: 0, got: %d", got)
+		}
+	})
+
+	t.Run("buffer with items", func(t *testing.T) {
+		testItems := []int{7, 8, 9}
+		for _, item := range testItems {
+			buffer.Push(item)
+		}
+		for _, want := range testItems {
+			got, err := buffer.TryPop()
 			if err != nil {
-				t.Fatal(err)
+				t.Errorf("didn't expect an error: %v", err)
 			}
-			for i := 0; i < tc.itemsAmount; i++ {
-				buffer.Push(42)
+			if got != want {
+				t.Errorf("want: %d, got: %d", want, got)
 			}
+		}
 
-			got := buffer.IsEmpty()
-			if got != tc.want {
-				t.Errorf("want: %t, got %t", tc.want, got)
-			}
+		_, err := buffer.TryPop()
+		if !errors.Is(err, ErrBufferIsEmpty) {
+			t.Errorf("expected err: %v, got err: %v", ErrBufferIsEmpty, err)
+		}
+	})
+}
 
-			for {
-				_, ok := buffer.Pop()
-				if !ok {
-					break
-				}
-			}
+func TestRingBufferPopTimeout(t *testing.T) {
+	t.Run("element arrives in time", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 2, 0)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			buffer.Push(1)
+		}()
+		if item, ok := buffer.PopTimeout(time.Second); !ok || item != 1 {
+			t.Errorf("PopTimeout(): want 1, true, got %d, %t", item, ok)
+		}
+	})
 
-			if !buffer.IsEmpty() {
-				t.Errorf("buffer should be empty: got size:%d", buffer.Size())
-			}
-		})
-	}
-}
+	t.Run("timeout", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 2, 0)
+		start := time.Now()
+		if item, ok := buffer.PopTimeout(20 * time.Millisecond); ok || item != 0 {
+			t.Errorf("PopTimeout(): want 0, false, got %d, %t", item, ok)
+		}
+		if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+			t.Errorf("PopTimeout returned after %v, before the timeout", elapsed)
+		}
+	})
 
-func TestRingBufferPushMultiThreading(t *testing.T) {
-	generateTestItems := func(itemCount int) []int {
-		items := make([]int, itemCount)
-		for i := 0; i < itemCount; i++ {
-			items[i] = rand.Intn(100)
+	t.Run("non-empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 2, 0, 1, 2)
+		if item, ok := buffer.PopTimeout(time.Second); !ok || item != 1 {
+			t.Errorf("PopTimeout(): want 1, true, got %d, %t", item, ok)
 		}
-		return items
-	}
+		if item, ok := buffer.PopTimeout(0); !ok || item != 2 {
+			t.Errorf("PopTimeout(): want 2, true, got %d, %t", item, ok)
+		}
+	})
+}
 
+func TestRingBufferPushPop(t *testing.T) {
 	testCases := []struct {
-		bufCapacity   int
-		testItemCount int
-		gorAmount     int
+		name       string
+		buffer     *ringBuffer[int]
+		want       int
+		wantOk     bool
+		wantBuffer []int
 	}{
-		{bufCapacity: 100, testItemCount: 100, gorAmount: 4},
-		{bufCapacity: 22, testItemCount: 55, gorAmount: 11},
-		{bufCapacity: 101, testItemCount: 100, gorAmount: 1},
-		{bufCapacity: 3, testItemCount: 100, gorAmount: 10},
-		{bufCapacity: 100, testItemCount: 55, gorAmount: 5},
-		{bufCapacity: 10, testItemCount: 100, gorAmount: 7},
-		{bufCapacity: 1000, testItemCount: 987, gorAmount: 41},
-		{bufCapacity: 75, testItemCount: 50, gorAmount: 2},
+		{name: "empty", buffer: newWrappedBuffer[int](t, 3, 0), wantBuffer: []int{7}},
+		{name: "partial", buffer: newWrappedBuffer(t, 3, 0, 1, 2), want: 1, wantOk: true, wantBuffer: []int{2, 7}},
+		{name: "full", buffer: newWrappedBuffer(t, 3, 0, 1, 2, 3), want: 1, wantOk: true, wantBuffer: []int{2, 3, 7}},
+		{name: "wrapped", buffer: newWrappedBuffer(t, 3, 1, 1, 2, 3, 4), want: 2, wantOk: true, wantBuffer: []int{3, 4, 7}},
 	}
 
 	for _, tc := range testCases {
-		fmtStr := "cap: %d, items: %d, goroutines: %d"
-		name := fmt.Sprintf(fmtStr, tc.bufCapacity, tc.testItemCount, tc.gorAmount)
-		t.Run(name, func(t *testing.T) {
-			var wg sync.WaitGroup
-			testItems := generateTestItems(tc.testItemCount)
-			buffer, err := New[int](tc.bufCapacity)
-			if err != nil {
-				t.Fatal(err)
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := tc.buffer.PushPop(7)
+			if got != tc.want || ok != tc.wantOk {
+				t.Errorf("PushPop(7): want %d, %t, got %d, %t", tc.want, tc.wantOk, got, ok)
 			}
-
-			itemChan := make(chan int, tc.gorAmount)
-			for i := 0; i < tc.gorAmount; i++ {
-				wg.Add(1)
-				go func() {
-					defer wg.Done()
-					for item := range itemChan {
-						buffer.Push(item)
-					}
-				}()
+			if !reflect.DeepEqual(tc.buffer.Snapshot(), tc.wantBuffer) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantBuffer, tc.buffer.Snapshot())
 			}
+		})
+	}
+}
 
-			for i := 0; i < tc.testItemCount; i++ {
-				itemChan <- testItems[i]
-			}
-			close(itemChan)
+func TestRingBufferPopC(t *testing.T) {
+	buffer := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5)
 
-			wg.Wait()
+	for _, want := range []int{3, 4, 5} {
+		item, ok, remaining := buffer.PopC()
+		if !ok || item != want {
+			t.Errorf("PopC() item: want %d, true, got %d, %t", want, item, ok)
+		}
+		if remaining != buffer.Size() {
+			t.Errorf("remaining: want %d, got %d", buffer.Size(), remaining)
+		}
+	}
 
-			if buffer.Size() != min(tc.testItemCount, cap(buffer.data)) {
-				t.Errorf("items in buffer: want %d, got %d", tc.testItemCount, buffer.Size())
-			}
-		})
+	item, ok, remaining := buffer.PopC()
+	if ok || item != 0 || remaining != 0 {
+		t.Errorf("PopC() on empty buffer: want 0, false, 0, got %d, %t, %d", item, ok, remaining)
 	}
 }
 
-func TestRingBufferNew(t *testing.T) {
-	t.Run("capacity 0", func(t *testing.T) {
-		capacity := 0
-		_, err := New[struct{}](capacity)
-		if !errors.Is(err, ErrInvalidBuffCap) {
-			t.Errorf("want error: %s, got error: %s", ErrInvalidBuffCap, err)
+func TestRingBufferPopIf(t *testing.T) {
+	isEven := func(n int) bool { return n%2 == 0 }
+
+	t.Run("empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		got, ok := buffer.PopIf(isEven)
+		if ok || got != 0 {
+			t.Errorf("PopIf(): want 0, false, got %d, %t", got, ok)
 		}
 	})
 
-	t.Run("negative capacity", func(t *testing.T) {
-		capacity := -1
-		_, err := New[struct{}](capacity)
-		if !errors.Is(err, ErrInvalidBuffCap) {
-			t.Errorf("want error: %s, got error: %s", ErrInvalidBuffCap, err)
+	t.Run("predicate passes", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 3, 0, 2, 3)
+		got, ok := buffer.PopIf(isEven)
+		if !ok || got != 2 {
+			t.Errorf("PopIf(): want 2, true, got %d, %t", got, ok)
+		}
+		if want := []int{3}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
 		}
 	})
 
-	t.Run("positive capacity", func(t *testing.T) {
-		capacity := 1
-		_, err := New[struct{}](capacity)
-		if !errors.Is(err, nil) {
-			t.Errorf("want error: %s, got error: %s", ErrInvalidBuffCap, err)
+	t.Run("predicate fails", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 3, 0, 3, 2)
+		got, ok := buffer.PopIf(isEven)
+		if ok || got != 3 {
+			t.Errorf("PopIf(): want 3, false, got %d, %t", got, ok)
+		}
+		if want := []int{3, 2}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+		}
+	})
+
+	t.Run("concurrent consumers", func(t *testing.T) {
+		// Each element must be popped by exactly one consumer.
+		itemCount := 1000
+		buffer, err := New[int](itemCount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < itemCount; i++ {
+			buffer.Push(i)
+		}
+
+		var popped sync.Map
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for !buffer.IsEmpty() {
+					if item, ok := buffer.PopIf(func(int) bool { return true }); ok {
+						if _, loaded := popped.LoadOrStore(item, true); loaded {
+							t.Errorf("item %d popped twice", item)
+						}
+					}
+				}
+			}()
 		}
+		wg.Wait()
 	})
 }
 
-func TestRingBufferContainsAllItems(t *testing.T) {
-	gorAmount := 99
-	itemCount := 12345
-	buffer, err := New[int](itemCount)
+func TestRingBufferPopWhile(t *testing.T) {
+	lessThan := func(n int) func(int) bool {
+		return func(item int) bool { return item < n }
+	}
+
+	testCases := []struct {
+		name      string
+		pred      func(int) bool
+		wantItems []int
+		wantLeft  []int
+	}{
+		{name: "prefix matches", pred: lessThan(5), wantItems: []int{3, 4}, wantLeft: []int{5, 6}},
+		{name: "nothing matches", pred: lessThan(3), wantItems: nil, wantLeft: []int{3, 4, 5, 6}},
+		{name: "everything matches", pred: lessThan(7), wantItems: []int{3, 4, 5, 6}, wantLeft: []int{}},
+		{name: "stops at first mismatch", pred: func(n int) bool { return n != 4 }, wantItems: []int{3}, wantLeft: []int{4, 5, 6}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5, 6)
+			if got := buffer.PopWhile(tc.pred); !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("popped items: want %v, got %v", tc.wantItems, got)
+			}
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.wantLeft) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantLeft, got)
+			}
+		})
+	}
+
+	t.Run("empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		if got := buffer.PopWhile(lessThan(10)); got != nil {
+			t.Errorf("popped items: want nil, got %v", got)
+		}
+	})
+}
+
+func TestRingBufferPopNewest(t *testing.T) {
+	buffer, err := New[int](4)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	itemChan := make(chan int)
-	testItems := make([]int, itemCount)
+	if _, ok := buffer.PopNewest(); ok {
+		t.Errorf("expected ok: false on empty buffer")
+	}
 
-	for i := 0; i < itemCount; i++ {
-		testItems[i] = i
+	// Each step is an operation followed by the expected buffer contents.
+	steps := []struct {
+		op        string
+		item      int
+		wantPop   int
+		wantItems []int
+	}{
+		{op: "push", item: 1, wantItems: []int{1}},
+		{op: "push", item: 2, wantItems: []int{1, 2}},
+		{op: "push", item: 3, wantItems: []int{1, 2, 3}},
+		{op: "popNewest", wantPop: 3, wantItems: []int{1, 2}},
+		{op: "push", item: 4, wantItems: []int{1, 2, 4}},
+		{op: "push", item: 5, wantItems: []int{1, 2, 4, 5}},
+		{op: "pop", wantPop: 1, wantItems: []int{2, 4, 5}},
+		{op: "pop", wantPop: 2, wantItems: []int{4, 5}},
+		{op: "push", item: 6, wantItems: []int{4, 5, 6}}, // writer wraps around
+		{op: "push", item: 7, wantItems: []int{4, 5, 6, 7}},
+		{op: "popNewest", wantPop: 7, wantItems: []int{4, 5, 6}},
+		{op: "popNewest", wantPop: 6, wantItems: []int{4, 5}}, // writer unwinds
+		{op: "push", item: 8, wantItems: []int{4, 5, 8}},
+		{op: "pop", wantPop: 4, wantItems: []int{5, 8}},
+		{op: "popNewest", wantPop: 8, wantItems: []int{5}},
+		{op: "popNewest", wantPop: 5, wantItems: []int{}},
+		{op: "push", item: 9, wantItems: []int{9}},
 	}
 
-	var wg sync.WaitGroup
-	for i := 0; i < gorAmount; i++ {
-		wg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			for item := range itemChan {
+	for i, step := range steps {
+		switch step.op {
+		case "push":
+			buffer.Push(step.item)
+		case "pop":
+			if got, ok := buffer.Pop(); !ok || got != step.wantPop {
+				t.Errorf("step %d: Pop(): want %d, got %d", i, step.wantPop, got)
+			}
+		case "popNewest":
+			if got, ok := buffer.PopNewest(); !ok || got != step.wantPop {
+				t.Errorf("step %d: PopNewest(): want %d, got %d", i, step.wantPop, got)
+			}
+		}
+		if got := buffer.Snapshot(); !reflect.DeepEqual(got, step.wantItems) {
+			t.Errorf("step %d: buffer items: want %v, got %v", i, step.wantItems, got)
+		}
+	}
+}
+
+func TestRingBufferPopAll(t *testing.T) {
+	testCases := []struct {
+		name      string
+		bufCap    int
+		pushItems []int
+		popCount  int
+		wantItems []int
+	}{
+		{name: "empty buffer", bufCap: 3, pushItems: []int{}, wantItems: []int{}},
+		{name: "partially filled", bufCap: 5, pushItems: []int{1, 2, 3}, wantItems: []int{1, 2, 3}},
+		{name: "full buffer", bufCap: 3, pushItems: []int{1, 2, 3}, wantItems: []int{1, 2, 3}},
+		{
+			name:      "wrapped buffer",
+			bufCap:    4,
+			pushItems: []int{1, 2, 3, 4, 5, 6, 7},
+			popCount:  3,
+			wantItems: []int{4, 5, 6, 7},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := New[int](tc.bufCap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, item := range tc.pushItems {
+				if i == tc.bufCap {
+					for j := 0; j < tc.popCount; j++ {
+						buffer.Pop()
+					}
+				}
 				buffer.Push(item)
 			}
-		}(i)
+			size := buffer.Size()
+
+			got := buffer.PopAll()
+			if len(got) != size {
+				t.Errorf("popped items: want %d, got %d", size, len(got))
+			}
+			if !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("popped items: want %v, got %v", tc.wantItems, got)
+			}
+			if !buffer.IsEmpty() {
+				t.Errorf("empty buffer expected, got size: %d", buffer.Size())
+			}
+
+			// The buffer must be reusable afterwards.
+			buffer.Push(42)
+			if item, _ := buffer.Pop(); item != 42 {
+				t.Errorf("Pop() item: want 42, got %d", item)
+			}
+		})
 	}
+}
 
-	for i := 0; i < itemCount; i++ {
-		itemChan <- testItems[i]
+func TestRingBufferPopInto(t *testing.T) {
+	testCases := []struct {
+		name      string
+		dstLen    int
+		wantCount int
+		wantDst   []int
+		wantItems []int
+	}{
+		{name: "empty dst", dstLen: 0, wantCount: 0, wantDst: []int{}, wantItems: []int{3, 4, 5, 6}},
+		{name: "smaller dst", dstLen: 3, wantCount: 3, wantDst: []int{3, 4, 5}, wantItems: []int{6}},
+		{name: "equal dst", dstLen: 4, wantCount: 4, wantDst: []int{3, 4, 5, 6}, wantItems: []int{}},
+		{name: "larger dst", dstLen: 6, wantCount: 4, wantDst: []int{3, 4, 5, 6, -1, -1}, wantItems: []int{}},
 	}
-	close(itemChan)
 
-	wg.Wait()
-	sort.Ints(buffer.data)
-	if !reflect.DeepEqual(buffer.data, testItems) {
-		t.Errorf("items in buffer do not match test data")
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5, 6)
+			dst := make([]int, tc.dstLen)
+			for i := range dst {
+				dst[i] = -1
+			}
+
+			if got := buffer.PopInto(dst); got != tc.wantCount {
+				t.Errorf("popped elements: want %d, got %d", tc.wantCount, got)
+			}
+			if !reflect.DeepEqual(dst, tc.wantDst) {
+				t.Errorf("dst: want %v, got %v", tc.wantDst, dst)
+			}
+			if buffer.Size() != len(tc.wantItems) {
+				t.Errorf("buffer size: want %d, got %d", len(tc.wantItems), buffer.Size())
+			}
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItems, got)
+			}
+			// The vacated cells must be zeroed.
+			zeros := 0
+			for _, item := range buffer.data {
+				if item == 0 {
+					zeros++
+				}
+			}
+			if zeros != buffer.Capacity()-buffer.Size() {
+				t.Errorf("zeroed cells: want %d, got %d", buffer.Capacity()-buffer.Size(), zeros)
+			}
+		})
 	}
 }
 
-func TestRingBufferGetString(t *testing.T) {
-	buffer, err := New[string](3)
-	if err != nil {
-		t.Fatal(err)
+func TestRingBufferDiscard(t *testing.T) {
+	testCases := []struct {
+		name          string
+		n             int
+		wantDiscarded int
+		wantNext      int
+		wantOk        bool
+	}{
+		{name: "zero", n: 0, wantDiscarded: 0, wantNext: 3, wantOk: true},
+		{name: "negative", n: -1, wantDiscarded: 0, wantNext: 3, wantOk: true},
+		{name: "one", n: 1, wantDiscarded: 1, wantNext: 4, wantOk: true},
+		{name: "across wrap", n: 3, wantDiscarded: 3, wantNext: 6, wantOk: true},
+		{name: "all", n: 5, wantDiscarded: 5, wantOk: false},
+		{name: "more than size", n: 10, wantDiscarded: 5, wantOk: false},
 	}
 
-	t.Run("empty buffer", func(t *testing.T) {
-		got, ok := buffer.Get()
-		if got != "" {
-			t.Errorf("expected empty string, got %q", got)
-		}
-		if ok != false {
-			t.Errorf("expected ok: false, got ok: %t", ok)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Logical order: [3 4 5 6 7]
+			buffer := newWrappedBuffer(t, 5, 2, 1, 2, 3, 4, 5, 6, 7)
+			size := buffer.Size()
+
+			if got := buffer.Discard(tc.n); got != tc.wantDiscarded {
+				t.Errorf("discarded: want %d, got %d", tc.wantDiscarded, got)
+			}
+			if buffer.Size() != size-tc.wantDiscarded {
+				t.Errorf("buffer size: want %d, got %d", size-tc.wantDiscarded, buffer.Size())
+			}
+			got, ok := buffer.Pop()
+			if ok != tc.wantOk || got != tc.wantNext {
+				t.Errorf("Pop(): want %d, %t, got %d, %t", tc.wantNext, tc.wantOk, got, ok)
+			}
+		})
+	}
+}
+
+func TestRingBufferMove(t *testing.T) {
+	testCases := []struct {
+		name           string
+		dstCap         int
+		dstItems       []int
+		dstOpts        []Option[int]
+		n              int
+		wantMoved      int
+		wantSrc        []int
+		wantDstSize    int
+		wantOverwrites int
+	}{
+		{name: "ample room", dstCap: 5, n: 3, wantMoved: 3, wantSrc: []int{6}, wantDstSize: 3},
+		{name: "n exceeds size", dstCap: 5, n: 10, wantMoved: 4, wantSrc: []int{}, wantDstSize: 4},
+		{name: "zero n", dstCap: 5, n: 0, wantMoved: 0, wantSrc: []int{3, 4, 5, 6}, wantDstSize: 0},
+		{name: "smaller dst", dstCap: 2, n: 4, wantMoved: 4, wantSrc: []int{}, wantDstSize: 2, wantOverwrites: 2},
+		{name: "full dst", dstCap: 2, dstItems: []int{1, 2}, n: 2, wantMoved: 2, wantSrc: []int{5, 6}, wantDstSize: 2, wantOverwrites: 2},
+		{
+			name:        "full no-wrap dst",
+			dstCap:      2,
+			dstItems:    []int{1, 2},
+			dstOpts:     []Option[int]{WithNoWrap[int]()},
+			n:           2,
+			wantMoved:   0,
+			wantSrc:     []int{3, 4, 5, 6},
+			wantDstSize: 2,
+		},
+		{
+			name:        "no-wrap dst with room",
+			dstCap:      3,
+			dstItems:    []int{1},
+			dstOpts:     []Option[int]{WithNoWrap[int]()},
+			n:           4,
+			wantMoved:   2,
+			wantSrc:     []int{5, 6},
+			wantDstSize: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5, 6)
+			observer := &countingObserver{}
+			dst, err := New(tc.dstCap, append(tc.dstOpts, WithObserver[int](observer))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, item := range tc.dstItems {
+				dst.Push(item)
+			}
+			*observer = countingObserver{}
+
+			if got := src.Move(dst, tc.n); got != tc.wantMoved {
+				t.Errorf("moved elements: want %d, got %d", tc.wantMoved, got)
+			}
+			if got := src.Snapshot(); !reflect.DeepEqual(got, tc.wantSrc) {
+				t.Errorf("src items: want %v, got %v", tc.wantSrc, got)
+			}
+			if dst.Size() != tc.wantDstSize {
+				t.Errorf("dst size: want %d, got %d", tc.wantDstSize, dst.Size())
+			}
+			if observer.pushes != tc.wantMoved || observer.overwrites != tc.wantOverwrites {
+				t.Errorf("dst observer calls: want %d pushes, %d overwrites, got %+v", tc.wantMoved, tc.wantOverwrites, *observer)
+			}
+			// The moved elements follow the dst elements, overwriting the
+			// oldest ones if needed.
+			want := append(append([]int{}, tc.dstItems...), []int{3, 4, 5, 6}[:tc.wantMoved]...)
+			want = want[max(0, len(want)-tc.dstCap):]
+			if got := dst.Snapshot(); !reflect.DeepEqual(got, want) {
+				t.Errorf("dst items: want %v, got %v", want, got)
+			}
+		})
+	}
+
+	t.Run("opposite directions", func(t *testing.T) {
+		// Moving concurrently in both directions must not deadlock.
+		a := newWrappedBuffer(t, 100, 0, randomNumbers(50, 0, 10)...)
+		b := newWrappedBuffer(t, 100, 0, randomNumbers(50, 0, 10)...)
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				a.Move(b, 1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				b.Move(a, 1)
+			}
+		}()
+		wg.Wait()
+		if a.Size()+b.Size() != 100 {
+			t.Errorf("total size: want 100, got %d", a.Size()+b.Size())
 		}
 	})
 
-	t.Run("buffer with items", func(t *testing.T) {
-		buffer.Push("apple")
-		buffer.Push("orange")
-		want := "apple"
-		got, ok := buffer.Get()
-		if got != want {
-			t.Errorf("expected %s, got %q", want, got)
+	t.Run("same buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 3, 0, 1, 2)
+		if got := buffer.Move(buffer, 2); got != 0 {
+			t.Errorf("moved elements: want 0, got %d", got)
 		}
-		if ok != true {
-			t.Errorf("expected ok: true, got ok: %t", ok)
+		if want := []int{1, 2}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
 		}
 	})
 }
 
-func TestRingBufferClear(t *testing.T) {
-	itemCount := 100
-	buffer, err := New[int](itemCount)
+func TestRingBufferIsEmpty(t *testing.T) {
+	testCases := []struct {
+		bufCapacity int
+		itemsAmount int
+		want        bool
+	}{
+		{bufCapacity: 1, itemsAmount: 0, want: true},
+		{bufCapacity: 1, itemsAmount: 1, want: false},
+		{bufCapacity: 5, itemsAmount: 2, want: false},
+		{bufCapacity: 3, itemsAmount: 8, want: false},
+		{bufCapacity: 5, itemsAmount: 0, want: true},
+		{bufCapacity: 5, itemsAmount: 1, want: false},
+		{bufCapacity: 5, itemsAmount: 6, want: false},
+	}
+
+	for _, tc := range testCases {
+		name := fmt.Sprintf("items: %d, cap: %d", tc.bufCapacity, tc.itemsAmount)
+		t.Run(name, func(t *testing.T) {
+			buffer, err := New[int](tc.bufCapacity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tc.itemsAmount; i++ {
+				buffer.Push(42)
+			}
+
+			got := buffer.IsEmpty()
+			if got != tc.want {
+				t.Errorf("want: %t, got %t", tc.want, got)
+			}
+
+			for {
+				_, ok := buffer.Pop()
+				if !ok {
+					break
+				}
+			}
+
+			if !buffer.IsEmpty() {
+				t.Errorf("buffer should be empty: got size:%d", buffer.Size())
+			}
+		})
+	}
+}
+
+func TestRingBufferIsEmptyConcurrent(t *testing.T) {
+	gorAmount := 20
+	opCount := 1000
+	buffer, err := New[int](10)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	for i := 0; i < itemCount; i++ {
-		buffer.Push(42)
-	}
-	if buffer.IsEmpty() {
-		t.Errorf("non-empty buffer expected")
+	var wg sync.WaitGroup
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.Push(j)
+				buffer.IsEmpty()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				if !buffer.IsEmpty() {
+					buffer.Pop()
+				}
+			}
+		}()
 	}
+	wg.Wait()
 
-	buffer.Clear()
+	if buffer.IsEmpty() != (buffer.Size() == 0) {
+		t.Errorf("IsEmpty() = %t does not match size %d", buffer.IsEmpty(), buffer.Size())
+	}
+	buffer.PopAll()
 	if !buffer.IsEmpty() {
 		t.Errorf("empty buffer expected")
 	}
+}
+
+func TestRingBufferPushMultiThreading(t *testing.T) {
+	generateTestItems := func(itemCount int) []int {
+		items := make([]int, itemCount)
+		for i := 0; i < itemCount; i++ {
+			items[i] = rand.Intn(100)
+		}
+		return items
+	}
+
+	testCases := []struct {
+		bufCapacity   int
+		testItemCount int
+		gorAmount     int
+	}{
+		{bufCapacity: 100, testItemCount: 100, gorAmount: 4},
+		{bufCapacity: 22, testItemCount: 55, gorAmount: 11},
+		{bufCapacity: 101, testItemCount: 100, gorAmount: 1},
+		{bufCapacity: 3, testItemCount: 100, gorAmount: 10},
+		{bufCapacity: 100, testItemCount: 55, gorAmount: 5},
+		{bufCapacity: 10, testItemCount: 100, gorAmount: 7},
+		{bufCapacity: 1000, testItemCount: 987, gorAmount: 41},
+		{bufCapacity: 75, testItemCount: 50, gorAmount: 2},
+	}
+
+	for _, tc := range testCases {
+		fmtStr := "cap: %d, items: %d, goroutines: %d"
+		name := fmt.Sprintf(fmtStr, tc.bufCapacity, tc.testItemCount, tc.gorAmount)
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			testItems := generateTestItems(tc.testItemCount)
+			buffer, err := New[int](tc.bufCapacity)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			itemChan := make(chan int, tc.gorAmount)
+			for i := 0; i < tc.gorAmount; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for item := range itemChan {
+						buffer.Push(item)
+					}
+				}()
+			}
+
+			for i := 0; i < tc.testItemCount; i++ {
+				itemChan <- testItems[i]
+			}
+			close(itemChan)
+
+			wg.Wait()
+
+			if buffer.Size() != min(tc.testItemCount, cap(buffer.data)) {
+				t.Errorf("items in buffer: want %d, got %d", tc.testItemCount, buffer.Size())
+			}
+		})
+	}
+}
+
+func TestRingBufferNew(t *testing.T) {
+	t.Run("capacity 0", func(t *testing.T) {
+		capacity := 0
+		_, err := New[struct{}](capacity)
+		if !errors.Is(err, ErrInvalidBuffCap) {
+			t.Errorf("want error: %s, got error: %s", ErrInvalidBuffCap, err)
+		}
+	})
+
+	t.Run("negative capacity", func(t *testing.T) {
+		capacity := -1
+		_, err := New[struct{}](capacity)
+		if !errors.Is(err, ErrInvalidBuffCap) {
+			t.Errorf("want error: %s, got error: %s", ErrInvalidBuffCap, err)
+		}
+	})
+
+	t.Run("positive capacity", func(t *testing.T) {
+		capacity := 1
+		_, err := New[struct{}](capacity)
+		if !errors.Is(err, nil) {
+			t.Errorf("want error: %s, got error: %s", ErrInvalidBuffCap, err)
+		}
+	})
+}
+
+func TestMustNew(t *testing.T) {
+	t.Run("invalid capacity", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r != ErrInvalidBuffCap {
+				t.Errorf("want panic with %v, got %v", ErrInvalidBuffCap, r)
+			}
+		}()
+		MustNew[int](0)
+	})
+
+	t.Run("valid capacity", func(t *testing.T) {
+		buffer := MustNew[int](2)
+		if buffer.Capacity() != 2 {
+			t.Errorf("buffer capacity: want 2, got %d", buffer.Capacity())
+		}
+		buffer.Push(1)
+		if got, ok := buffer.Pop(); !ok || got != 1 {
+			t.Errorf("Pop(): want 1, true, got %d, %t", got, ok)
+		}
+	})
+}
+
+func TestRingBufferContainsAllItems(t *testing.T) {
+	gorAmount := 99
+	itemCount := 12345
+	buffer, err := New[int](itemCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	itemChan := make(chan int)
+	testItems := make([]int, itemCount)
+
+	for i := 0; i < itemCount; i++ {
+		testItems[i] = i
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for item := range itemChan {
+				buffer.Push(item)
+			}
+		}(i)
+	}
+
+	for i := 0; i < itemCount; i++ {
+		itemChan <- testItems[i]
+	}
+	close(itemChan)
+
+	wg.Wait()
+	sort.Ints(buffer.data)
+	if !reflect.DeepEqual(buffer.data, testItems) {
+		t.Errorf("items in buffer do not match test data")
+	}
+}
+
+func TestRingBufferGetString(t *testing.T) {
+	buffer, err := New[string](3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("empty buffer", func(t *testing.T) {
+		got, ok := buffer.Get()
+		if got != "" {
+			t.Errorf("expected empty string, got %q", got)
+		}
+		if ok != false {
+			t.Errorf("expected ok: false, got ok: %t", ok)
+		}
+	})
+
+	t.Run("buffer with items", func(t *testing.T) {
+		buffer.Push("apple")
+		buffer.Push("orange")
+		want := "apple"
+		got, ok := buffer.Get()
+		if got != want {
+			t.Errorf("expected %s, got %q", want, got)
+		}
+		if ok != true {
+			t.Errorf("expected ok: true, got ok: %t", ok)
+		}
+	})
+}
+
+func TestRingBufferSwapOldest(t *testing.T) {
+	t.Run("empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		old, ok := buffer.SwapOldest(1)
+		if ok || old != 0 {
+			t.Errorf("SwapOldest(1): want 0, false, got %d, %t", old, ok)
+		}
+		if !buffer.IsEmpty() {
+			t.Errorf("empty buffer expected, got size: %d", buffer.Size())
+		}
+	})
+
+	t.Run("buffer with items", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 3, 2, 1, 2, 3, 4)
+		old, ok := buffer.SwapOldest(10)
+		if !ok || old != 3 {
+			t.Errorf("SwapOldest(10): want 3, true, got %d, %t", old, ok)
+		}
+		if want := []int{10, 4}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+		}
+	})
+}
+
+func TestRingBufferEnds(t *testing.T) {
+	testCases := []struct {
+		name       string
+		buffer     *ringBuffer[int]
+		wantOldest int
+		wantNewest int
+		wantOk     bool
+	}{
+		{name: "empty", buffer: newWrappedBuffer[int](t, 3, 0)},
+		{name: "single", buffer: newWrappedBuffer(t, 3, 0, 1), wantOldest: 1, wantNewest: 1, wantOk: true},
+		{name: "contiguous", buffer: newWrappedBuffer(t, 3, 0, 1, 2), wantOldest: 1, wantNewest: 2, wantOk: true},
+		{name: "wrapped", buffer: newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5), wantOldest: 3, wantNewest: 5, wantOk: true},
+		{name: "overwritten", buffer: newWrappedBuffer(t, 3, 0, 1, 2, 3, 4, 5), wantOldest: 3, wantNewest: 5, wantOk: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			oldest, newest, ok := tc.buffer.Ends()
+			if oldest != tc.wantOldest || newest != tc.wantNewest || ok != tc.wantOk {
+				t.Errorf("Ends(): want %d, %d, %t, got %d, %d, %t",
+					tc.wantOldest, tc.wantNewest, tc.wantOk, oldest, newest, ok)
+			}
+		})
+	}
+}
+
+func TestRingBufferGetDeep(t *testing.T) {
+	type point struct{ x, y int }
+	clone := func(p *point) *point {
+		c := *p
+		return &c
+	}
+
+	buffer, err := New[*point](3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("empty buffer", func(t *testing.T) {
+		got, ok := buffer.GetDeep(clone)
+		if got != nil || ok {
+			t.Errorf("GetDeep(): want nil, false, got %v, %t", got, ok)
+		}
+	})
+
+	t.Run("buffer with items", func(t *testing.T) {
+		buffer.Push(&point{x: 1, y: 2})
+		got, ok := buffer.GetDeep(clone)
+		if !ok || *got != (point{x: 1, y: 2}) {
+			t.Fatalf("GetDeep(): want {1 2}, true, got %v, %t", got, ok)
+		}
+
+		got.x = 10
+		stored, _ := buffer.Get()
+		if *stored != (point{x: 1, y: 2}) {
+			t.Errorf("stored item: want {1 2}, got %v", *stored)
+		}
+
+		// Get shares the pointed data with the buffer.
+		stored.y = 20
+		if again, _ := buffer.Get(); again.y != 20 {
+			t.Errorf("stored item y: want 20, got %d", again.y)
+		}
+	})
+}
+
+func TestRingBufferCompact(t *testing.T) {
+	isZero := func(n int) bool { return n == 0 }
+
+	testCases := []struct {
+		name        string
+		items       []int
+		wantRemoved int
+		wantItems   []int
+	}{
+		{name: "no zeros", items: []int{9, 9, 1, 2, 3, 4}, wantRemoved: 0, wantItems: []int{1, 2, 3, 4}},
+		{name: "mixed", items: []int{9, 9, 1, 0, 2, 0}, wantRemoved: 2, wantItems: []int{1, 2}},
+		{name: "leading zeros", items: []int{9, 9, 0, 0, 1, 2}, wantRemoved: 2, wantItems: []int{1, 2}},
+		{name: "only zeros", items: []int{9, 9, 0, 0, 0, 0}, wantRemoved: 4, wantItems: []int{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The elements span the end of the backing array.
+			buffer := newWrappedBuffer(t, 5, 2, tc.items...)
+			if got := buffer.Compact(isZero); got != tc.wantRemoved {
+				t.Errorf("removed elements: want %d, got %d", tc.wantRemoved, got)
+			}
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItems, got)
+			}
+			if buffer.Size() != len(tc.wantItems) {
+				t.Errorf("buffer size: want %d, got %d", len(tc.wantItems), buffer.Size())
+			}
+
+			// The buffer must continue after the compacted elements.
+			buffer.Push(5)
+			want := append(tc.wantItems, 5)
+			if got := buffer.PopAll(); !reflect.DeepEqual(got, want) {
+				t.Errorf("popped items: want %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestRingBufferRotate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		n         int
+		wantLeft  []int
+		wantRight []int
+	}{
+		{name: "zero", n: 0, wantLeft: []int{3, 4, 5}, wantRight: []int{3, 4, 5}},
+		{name: "one", n: 1, wantLeft: []int{4, 5, 3}, wantRight: []int{5, 3, 4}},
+		{name: "two", n: 2, wantLeft: []int{5, 3, 4}, wantRight: []int{4, 5, 3}},
+		{name: "size", n: 3, wantLeft: []int{3, 4, 5}, wantRight: []int{3, 4, 5}},
+		{name: "larger than size", n: 7, wantLeft: []int{4, 5, 3}, wantRight: []int{5, 3, 4}},
+		{name: "negative", n: -1, wantLeft: []int{5, 3, 4}, wantRight: []int{4, 5, 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The elements span the end of the backing array.
+			buffer := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5)
+			buffer.RotateLeft(tc.n)
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.wantLeft) {
+				t.Errorf("RotateLeft(%d): want %v, got %v", tc.n, tc.wantLeft, got)
+			}
+
+			buffer = newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5)
+			buffer.RotateRight(tc.n)
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.wantRight) {
+				t.Errorf("RotateRight(%d): want %v, got %v", tc.n, tc.wantRight, got)
+			}
+
+			// The buffer must keep working after the rotation.
+			buffer.Push(6)
+			want := append(tc.wantRight, 6)
+			if got := buffer.PopAll(); !reflect.DeepEqual(got, want) {
+				t.Errorf("popped items: want %v, got %v", want, got)
+			}
+		})
+	}
+
+	t.Run("empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		buffer.RotateLeft(2)
+		buffer.RotateRight(2)
+		if !buffer.IsEmpty() {
+			t.Errorf("empty buffer expected")
+		}
+	})
+}
+
+func TestRingBufferReverse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		bufCap   int
+		popCount int
+		items    []int
+		want     []int
+	}{
+		{name: "empty", bufCap: 4, want: []int{}},
+		{name: "single", bufCap: 4, items: []int{1}, want: []int{1}},
+		{name: "contiguous odd", bufCap: 4, items: []int{1, 2, 3}, want: []int{3, 2, 1}},
+		{name: "contiguous even", bufCap: 5, items: []int{1, 2, 3, 4}, want: []int{4, 3, 2, 1}},
+		{name: "wrapped odd", bufCap: 4, popCount: 3, items: []int{1, 2, 3, 4, 5, 6}, want: []int{6, 5, 4}},
+		{name: "wrapped even", bufCap: 5, popCount: 2, items: []int{1, 2, 3, 4, 5, 6}, want: []int{6, 5, 4, 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, tc.bufCap, tc.popCount, tc.items...)
+			buffer.Reverse()
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("buffer items: want %v, got %v", tc.want, got)
+			}
+
+			// The buffer must keep working after the reversal.
+			buffer.Push(7)
+			want := append(tc.want, 7)
+			if got := buffer.PopAll(); !reflect.DeepEqual(got, want) {
+				t.Errorf("popped items: want %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestRingBufferShuffle(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	shuffle := func(seed int64) []int {
+		buffer := newWrappedBuffer(t, 10, 4, items...)
+		buffer.Shuffle(rand.New(rand.NewSource(seed)))
+		return buffer.Snapshot()
+	}
+
+	got := shuffle(42)
+	sorted := append([]int(nil), got...)
+	sort.Ints(sorted)
+	if want := []int{5, 6, 7, 8, 9, 10, 11, 12}; !reflect.DeepEqual(sorted, want) {
+		t.Errorf("shuffled items: want a permutation of %v, got %v", want, got)
+	}
+	if again := shuffle(42); !reflect.DeepEqual(again, got) {
+		t.Errorf("same seed: want %v, got %v", got, again)
+	}
+	if other := shuffle(7); reflect.DeepEqual(other, got) {
+		t.Errorf("different seeds gave the same order %v", got)
+	}
+}
+
+func TestRingBufferClear(t *testing.T) {
+	itemCount := 100
+	buffer, err := New[int](itemCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < itemCount; i++ {
+		buffer.Push(42)
+	}
+	if buffer.IsEmpty() {
+		t.Errorf("non-empty buffer expected")
+	}
+
+	buffer.Clear()
+	if !buffer.IsEmpty() {
+		t.Errorf("empty buffer expected")
+	}
+
+	if buffer.Size() != 0 {
+		t.Errorf("buffer size: want 0, got %d", buffer.Size())
+	}
+
+	got, ok := buffer.Get()
+	if got != 0 {
+		t.Errorf("expected empty buffer, got %d", got)
+	}
+	if ok != false {
+		t.Errorf("expected ok: false, got ok: %t", ok)
+	}
+
+	got, ok = buffer.Pop()
+	if got != 0 {
+		t.Errorf("expected empty buffer, got %d", got)
+	}
+	if ok != false {
+		t.Errorf("expected ok: false, got ok: %t", ok)
+	}
+}
+
+func TestRingBufferReplaceAll(t *testing.T) {
+	testCases := []struct {
+		name       string
+		opts       []Option[int]
+		items      []int
+		want       []int
+		wantPushes int
+	}{
+		{name: "empty", items: nil, want: []int{}},
+		{name: "fewer than capacity", items: []int{7, 8}, want: []int{7, 8}, wantPushes: 2},
+		{name: "exact capacity", items: []int{7, 8, 9, 10}, want: []int{7, 8, 9, 10}, wantPushes: 4},
+		{name: "overwrite", items: []int{7, 8, 9, 10, 11, 12}, want: []int{9, 10, 11, 12}, wantPushes: 6},
+		{
+			name:       "no wrap",
+			opts:       []Option[int]{WithNoWrap[int]()},
+			items:      []int{7, 8, 9, 10, 11, 12},
+			want:       []int{7, 8, 9, 10},
+			wantPushes: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			observer := &countingObserver{}
+			buffer, err := New(4, append(tc.opts, WithObserver[int](observer))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buffer.PushSlice([]int{1, 2, 3, 4, 5, 6})
+			*observer = countingObserver{}
+
+			buffer.ReplaceAll(tc.items)
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("buffer items: want %v, got %v", tc.want, got)
+			}
+			if observer.pushes != tc.wantPushes {
+				t.Errorf("observer pushes: want %d, got %d", tc.wantPushes, observer.pushes)
+			}
+		})
+	}
+
+	t.Run("concurrent readers", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 4, 0, 1, 1, 1, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ctx.Err() == nil {
+					got := buffer.Snapshot()
+					if len(got) != 4 || got[1] != got[0] || got[2] != got[0] || got[3] != got[0] {
+						t.Errorf("partial state: %v", got)
+						return
+					}
+				}
+			}()
+		}
+		for i := range 1000 {
+			item := i%2 + 1
+			buffer.ReplaceAll([]int{item, item, item, item})
+		}
+		cancel()
+		wg.Wait()
+	})
+}
+
+func TestRingBufferDeepClear(t *testing.T) {
+	itemCount := 100
+	buffer, err := New[int](itemCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < itemCount; i++ {
+		buffer.Push(42)
+	}
+	if buffer.IsEmpty() {
+		t.Errorf("non-empty buffer expected")
+	}
+
+	buffer.DeepClear()
+	if !buffer.IsEmpty() {
+		t.Errorf("empty buffer expected")
+	}
+
+	if buffer.Size() != 0 {
+		t.Errorf("buffer size: want 0, got %d", buffer.Size())
+	}
+
+	got, ok := buffer.Get()
+	if got != 0 {
+		t.Errorf("expected empty buffer, got %d", got)
+	}
+	if ok != false {
+		t.Errorf("expected ok: false, got ok: %t", ok)
+	}
+
+	got, ok = buffer.Pop()
+	if got != 0 {
+		t.Errorf("expected empty buffer, got %d", got)
+	}
+	if ok != false {
+		t.Errorf("expected ok: false, got ok: %t", ok)
+	}
+}
+
+func TestRingBufferReuseAfterDeepClear(t *testing.T) {
+	bufCapacity := 5
+	buffer, err := New[int](bufCapacity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fill the buffer and wrap it around, so the indices are moved away from
+	// their initial positions.
+	for i := 0; i < bufCapacity+3; i++ {
+		buffer.Push(i)
+	}
+	buffer.Pop()
+	buffer.DeepClear()
+
+	if buffer.writerIdx != 0 || buffer.readerIdx != 0 || buffer.lastWriterIdx != 0 || buffer.wrapped {
+		t.Errorf("buffer indices should be reset, got writer: %d, reader: %d, last writer: %d, wrapped: %t",
+			buffer.writerIdx, buffer.readerIdx, buffer.lastWriterIdx, buffer.wrapped)
+	}
+
+	testNumbers := []int{10, 20, 30}
+	for _, num := range testNumbers {
+		buffer.Push(num)
+	}
+	if buffer.Size() != len(testNumbers) {
+		t.Errorf("buffer size: want %d, got %d", len(testNumbers), buffer.Size())
+	}
+	for _, want := range testNumbers {
+		got, ok := buffer.Pop()
+		if !ok || got != want {
+			t.Errorf("Pop() item: want %d, got %d", want, got)
+		}
+	}
+	if !buffer.IsEmpty() {
+		t.Errorf("empty buffer expected")
+	}
+}
+
+func TestRingBufferClearRefs(t *testing.T) {
+	// The live elements 4, 5, 6 wrap around the end of the backing array.
+	buffer := newWrappedBuffer(t, 4, 3, 1, 2, 3, 4, 5, 6)
+	marker := 42
+	buffer.data[2] = marker // stale cell, not part of the buffer
+
+	buffer.ClearRefs()
+	if !buffer.IsEmpty() {
+		t.Errorf("empty buffer expected")
+	}
+	if buffer.writerIdx != 0 || buffer.readerIdx != 0 || buffer.lastWriterIdx != 0 || buffer.wrapped {
+		t.Errorf("buffer indices should be reset, got writer: %d, reader: %d, last writer: %d, wrapped: %t",
+			buffer.writerIdx, buffer.readerIdx, buffer.lastWriterIdx, buffer.wrapped)
+	}
+	// Only the cells of the live elements must be zeroed.
+	if want := []int{0, 0, marker, 0}; !reflect.DeepEqual(buffer.data, want) {
+		t.Errorf("buffer data: want %v, got %v", want, buffer.data)
+	}
+
+	buffer.Push(7)
+	if got := buffer.Snapshot(); !reflect.DeepEqual(got, []int{7}) {
+		t.Errorf("buffer items: want [7], got %v", got)
+	}
+}
+
+func TestRingBufferWarm(t *testing.T) {
+	// The popped elements are left in the vacated cells.
+	buffer, err := New(5, WithZeroOnPop[int](false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer.PushSlice([]int{1, 2, 3, 4, 5, 6, 7})
+	buffer.Discard(2)
+
+	buffer.Warm()
+	if buffer.Size() != 3 {
+		t.Errorf("buffer size: want 3, got %d", buffer.Size())
+	}
+	if want := []int{5, 6, 7}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+		t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+	}
+	if want := []int{6, 7, 0, 0, 5}; !reflect.DeepEqual(buffer.RawData(), want) {
+		t.Errorf("raw data: want %v, got %v", want, buffer.RawData())
+	}
+
+	buffer.PushSlice([]int{8, 9, 10})
+	if want := []int{6, 7, 8, 9, 10}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+		t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+	}
+}
+
+func TestRingBufferReuseAfterClear(t *testing.T) {
+	itemCount := 50
+	buffer, err := New[int](itemCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Initially fill the buffer, just to clear it then.
+	for i := 0; i < itemCount; i++ {
+		buffer.Push(42)
+	}
+	buffer.Clear()
+
+	testNumbers := randomNumbers(itemCount, -100, 100)
+	for _, num := range testNumbers {
+		buffer.Push(num)
+	}
+	if !buffer.IsFull() {
+		t.Errorf("expected full buffer")
+	}
+	gotSize := buffer.Size()
+	if gotSize != len(testNumbers) {
+		t.Errorf("buffer size: got %d, want %d", gotSize, len(testNumbers))
+	}
+	if !reflect.DeepEqual(buffer.data, testNumbers) {
+		t.Errorf("buffer data:\n got %v, \nwant %v", buffer.data, testNumbers)
+	}
+
+	idx := 0
+	for !buffer.IsEmpty() {
+		num, _ := buffer.Pop()
+		if num != testNumbers[idx] {
+			t.Errorf("Pop() item: got %d, want %d", num, testNumbers[idx])
+		}
+		idx++
+	}
+}
+
+func TestRingBufferIsFull(t *testing.T) {
+	testCases := []struct {
+		bufferCap int
+		itemCount int
+		want      bool
+	}{
+		{bufferCap: 1, itemCount: 0, want: false},
+		{bufferCap: 1, itemCount: 1, want: true},
+		{bufferCap: 10, itemCount: 9, want: false},
+		{bufferCap: 100, itemCount: 100, want: true},
+		{bufferCap: 120, itemCount: 0, want: false},
+		{bufferCap: 77, itemCount: 290, want: true},
+	}
+
+	for _, tc := range testCases {
+		name := fmt.Sprintf("cap: %d, items: %d", tc.bufferCap, tc.itemCount)
+		t.Run(name, func(t *testing.T) {
+			buffer, err := New[int](tc.bufferCap)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < tc.itemCount; i++ {
+				buffer.Push(1)
+			}
+
+			got := buffer.IsFull()
+			if got != tc.want {
+				t.Errorf("want: %t, got: %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRingBufferSize(t *testing.T) {
+	testCases := []struct {
+		name      string
+		bufferCap int
+		itemCount int
+		wantSize  int
+	}{
+		{bufferCap: 1, itemCount: 0, wantSize: 0},
+		{bufferCap: 1, itemCount: 1, wantSize: 1},
+		{bufferCap: 10, itemCount: 9, wantSize: 9},
+		{bufferCap: 100, itemCount: 100, wantSize: 100},
+		{bufferCap: 120, itemCount: 0, wantSize: 0},
+		{bufferCap: 77, itemCount: 290, wantSize: 77},
+		{bufferCap: 33, itemCount: 99, wantSize: 33},
+	}
+
+	for _, tc := range testCases {
+		name := fmt.Sprintf("cap: %d, items: %d", tc.bufferCap, tc.itemCount)
+		t.Run(name, func(t *testing.T) {
+			buffer, err := New[bool](tc.bufferCap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tc.itemCount; i++ {
+				buffer.Push(true)
+			}
+
+			if buffer.Size() != tc.wantSize {
+				t.Errorf("size: want %d, got %d", tc.wantSize, buffer.Size())
+			}
+		})
+	}
+}
+
+func TestRingBufferCapacity(t *testing.T) {
+	testCases := []struct {
+		name      string
+		bufferCap int
+	}{
+		{name: "fixed capacity", bufferCap: 4},
+		{name: "random capacity", bufferCap: rand.Intn(1000) + 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := New[int](tc.bufferCap)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if buffer.Capacity() != tc.bufferCap {
+				t.Errorf("buffer capacity: want %d, got %d", tc.bufferCap, buffer.Capacity())
+			}
+		})
+	}
+}
+
+func TestRingBufferTryPushConcurrent(t *testing.T) {
+	// TryPush must never overwrite, so exactly capacity calls succeed.
+	bufCap := 100
+	buffer, err := New[int](bufCap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var succeeded atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if buffer.TryPush(j) == nil {
+					succeeded.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := succeeded.Load(); got != int64(bufCap) {
+		t.Errorf("successful TryPush calls: want %d, got %d", bufCap, got)
+	}
+	if stats := buffer.StatsSnapshot(); stats.Overwritten != 0 {
+		t.Errorf("overwritten elements: want 0, got %d", stats.Overwritten)
+	}
+}
+
+func TestRingBufferSizeInvariant(t *testing.T) {
+	// A small full buffer is pushed to and popped from concurrently, so the
+	// overwrites race with the pops.
+	bufCap := 4
+	opCount := 5000
+	buffer := newWrappedBuffer(t, bufCap, 0, 1, 2, 3, 4)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.Push(j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.Pop()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.TryPush(j)
+				buffer.PopNewest()
+			}
+		}()
+	}
+
+	var violations atomic.Int64
+	var checker sync.WaitGroup
+	checker.Add(1)
+	go func() {
+		defer checker.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if size := buffer.Size(); size < 0 || size > bufCap {
+				violations.Add(1)
+			}
+		}
+	}()
+	wg.Wait()
+	close(done)
+	checker.Wait()
+
+	if got := violations.Load(); got != 0 {
+		t.Errorf("size out of [0, %d] observed %d times", bufCap, got)
+	}
+	// Every pushed element is either popped, overwritten, or still buffered.
+	stats := buffer.StatsSnapshot()
+	if live := stats.Pushed - stats.Popped - stats.Overwritten; live != uint64(stats.Size) {
+		t.Errorf("live elements: want %d, got %d (%+v)", stats.Size, live, stats)
+	}
+	if got := len(buffer.Snapshot()); got != buffer.Size() {
+		t.Errorf("snapshot length: want %d, got %d", buffer.Size(), got)
+	}
+}
+
+func TestRingBufferDetectDataRace(t *testing.T) {
+	bufferCap := 500
+	gorAmount := 100
+	opCount := 10_000
+
+	buffer, err := New[string](bufferCap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				item := fmt.Sprintf("item %d from goroutine %d", j, i)
+				buffer.Push(item)
+			}
+		}(i)
+	}
+
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.Size()
+			}
+		}()
+	}
+
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.Capacity()
+			}
+		}()
+	}
+
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.Get()
+			}
+		}()
+	}
+
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.Pop()
+			}
+		}()
+	}
+
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.IsEmpty()
+			}
+		}()
+	}
+
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.IsFull()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestRingBufferReset(t *testing.T) {
+	testCases := []struct {
+		bufCapacity int
+		itemCount   int
+		newCap      int
+	}{
+		{bufCapacity: 5, itemCount: 3, newCap: 10},
+		{bufCapacity: 10, itemCount: 10, newCap: 4},
+		{bufCapacity: 3, itemCount: 7, newCap: 3},
+		{bufCapacity: 1, itemCount: 0, newCap: 1},
+	}
+
+	for _, tc := range testCases {
+		name := fmt.Sprintf("cap: %d, items: %d, new cap: %d", tc.bufCapacity, tc.itemCount, tc.newCap)
+		t.Run(name, func(t *testing.T) {
+			buffer, err := New[int](tc.bufCapacity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tc.itemCount; i++ {
+				buffer.Push(i + 1)
+			}
+			backing := buffer.data[:cap(buffer.data)]
+
+			if err := buffer.Reset(tc.newCap); err != nil {
+				t.Fatalf("didn't expect an error: %v", err)
+			}
+			if buffer.Capacity() != tc.newCap {
+				t.Errorf("buffer capacity: want %d, got %d", tc.newCap, buffer.Capacity())
+			}
+			if !buffer.IsEmpty() {
+				t.Errorf("empty buffer expected, got size: %d", buffer.Size())
+			}
+			if !reflect.DeepEqual(buffer.data, make([]int, tc.newCap)) {
+				t.Errorf("buffer data: want zero values, got %v", buffer.data)
+			}
+			// A reused array must not retain the elements past the new capacity.
+			if tc.newCap <= tc.bufCapacity && !reflect.DeepEqual(backing, make([]int, len(backing))) {
+				t.Errorf("backing array: want zero values, got %v", backing)
+			}
+
+			// The buffer must be usable up to its new capacity.
+			for i := 0; i < tc.newCap; i++ {
+				buffer.Push(i)
+			}
+			if !buffer.IsFull() {
+				t.Errorf("expected full buffer")
+			}
+			for i := 0; i < tc.newCap; i++ {
+				item, _ := buffer.Pop()
+				if item != i {
+					t.Errorf("Pop() item: want %d, got %d", i, item)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid capacity", func(t *testing.T) {
+		buffer, err := New[int](3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.Push(1)
+
+		err = buffer.Reset(0)
+		if !errors.Is(err, ErrInvalidBuffCap) {
+			t.Errorf("want error: %s, got error: %s", ErrInvalidBuffCap, err)
+		}
+		if buffer.Capacity() != 3 || buffer.Size() != 1 {
+			t.Errorf("buffer should be unchanged on error")
+		}
+	})
+}
+
+func TestRingBufferResetConcurrent(t *testing.T) {
+	gorAmount := 10
+	opCount := 2000
+	buffer, err := New[int](16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reset changes the capacity, which the other methods read concurrently,
+	// so this must pass the race detector. Capacity is called on its own, so
+	// that the locking of the other methods doesn't hide a race.
+	var wg sync.WaitGroup
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.Push(j)
+				buffer.IsFull()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.Capacity()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			caps := []int{1, 7, 32, 3}
+			for j := 0; j < opCount/10; j++ {
+				if err := buffer.Reset(caps[j%len(caps)]); err != nil {
+					t.Errorf("didn't expect an error: %v", err)
+				}
+				buffer.Size()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if size := buffer.Size(); size < 0 || size > buffer.Capacity() {
+		t.Errorf("buffer size %d out of range [0, %d]", size, buffer.Capacity())
+	}
+}
+
+func TestRingBufferPeekOldestNewestN(t *testing.T) {
+	testCases := []struct {
+		name       string
+		bufCap     int
+		pushItems  []int
+		popCount   int
+		n          int
+		wantOldest []int
+		wantNewest []int
+	}{
+		{
+			name:       "empty buffer",
+			bufCap:     3,
+			pushItems:  []int{},
+			n:          2,
+			wantOldest: []int{},
+			wantNewest: []int{},
+		},
+		{
+			name:       "contiguous",
+			bufCap:     5,
+			pushItems:  []int{1, 2, 3, 4},
+			n:          2,
+			wantOldest: []int{1, 2},
+			wantNewest: []int{3, 4},
+		},
+		{
+			name:       "wrapped",
+			bufCap:     4,
+			pushItems:  []int{1, 2, 3, 4, 5, 6},
+			popCount:   2,
+			n:          3,
+			wantOldest: []int{3, 4, 5},
+			wantNewest: []int{4, 5, 6},
+		},
+		{
+			name:       "n larger than size",
+			bufCap:     4,
+			pushItems:  []int{1, 2, 3, 4, 5},
+			popCount:   2,
+			n:          10,
+			wantOldest: []int{3, 4, 5},
+			wantNewest: []int{3, 4, 5},
+		},
+		{
+			name:       "negative n",
+			bufCap:     3,
+			pushItems:  []int{1, 2},
+			n:          -1,
+			wantOldest: []int{},
+			wantNewest: []int{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := New[int](tc.bufCap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Pop in between pushes, so the buffer wraps without overwriting.
+			for i, item := range tc.pushItems {
+				if i == tc.bufCap {
+					for j := 0; j < tc.popCount; j++ {
+						buffer.Pop()
+					}
+				}
+				buffer.Push(item)
+			}
+			size := buffer.Size()
+
+			if got := buffer.PeekOldestN(tc.n); !reflect.DeepEqual(got, tc.wantOldest) {
+				t.Errorf("PeekOldestN(%d): want %v, got %v", tc.n, tc.wantOldest, got)
+			}
+			if got := buffer.PeekNewestN(tc.n); !reflect.DeepEqual(got, tc.wantNewest) {
+				t.Errorf("PeekNewestN(%d): want %v, got %v", tc.n, tc.wantNewest, got)
+			}
+			if buffer.Size() != size {
+				t.Errorf("buffer size changed: want %d, got %d", size, buffer.Size())
+			}
+		})
+	}
+}
+
+func TestRingBufferGetN(t *testing.T) {
+	testCases := []struct {
+		n    int
+		want []int
+	}{
+		{n: -1, want: []int{}},
+		{n: 0, want: []int{}},
+		{n: 2, want: []int{3, 4}},
+		{n: 4, want: []int{3, 4, 5, 6}},
+		{n: 10, want: []int{3, 4, 5, 6}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("n: %d", tc.n), func(t *testing.T) {
+			buffer := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5, 6)
+			got := buffer.GetN(tc.n)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("GetN(%d): want %v, got %v", tc.n, tc.want, got)
+			}
+			if buffer.Size() != 4 {
+				t.Errorf("buffer size: want 4, got %d", buffer.Size())
+			}
+
+			// The result must be a copy.
+			for i := range got {
+				got[i] = 0
+			}
+			if want := []int{3, 4, 5, 6}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+				t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+			}
+		})
+	}
+}
+
+func TestRingBufferIter(t *testing.T) {
+	t.Run("mutation during iteration", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5, 6)
+		var got []int
+		for item := range buffer.Iter() {
+			got = append(got, item)
+			// Must not deadlock or affect the yielded elements.
+			buffer.Pop()
+			buffer.Push(item * 10)
+		}
+		if want := []int{3, 4, 5, 6}; !reflect.DeepEqual(got, want) {
+			t.Errorf("yielded items: want %v, got %v", want, got)
+		}
+		if want := []int{30, 40, 50, 60}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+		}
+	})
+
+	t.Run("break", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 4, 0, 1, 2, 3)
+		var got []int
+		for item := range buffer.Iter() {
+			if item == 3 {
+				break
+			}
+			got = append(got, item)
+		}
+		if want := []int{1, 2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("yielded items: want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestRingBufferChunkIter(t *testing.T) {
+	testCases := []struct {
+		name  string
+		chunk int
+		want  [][]int
+	}{
+		{name: "uneven chunks", chunk: 3, want: [][]int{{3, 4, 5}, {6, 7, 8}, {9}}},
+		{name: "even chunks", chunk: 7, want: [][]int{{3, 4, 5, 6, 7, 8, 9}}},
+		{name: "chunk exceeds size", chunk: 10, want: [][]int{{3, 4, 5, 6, 7, 8, 9}}},
+		{name: "non-positive chunk", chunk: 0, want: [][]int{{3}, {4}, {5}, {6}, {7}, {8}, {9}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, 8, 2, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+			var got [][]int
+			for items := range buffer.ChunkIter(tc.chunk) {
+				got = append(got, items)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("yielded chunks: want %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 4, 0)
+		for items := range buffer.ChunkIter(2) {
+			t.Errorf("unexpected chunk: %v", items)
+		}
+	})
+
+	t.Run("break", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 4, 0, 1, 2, 3, 4)
+		var got [][]int
+		for items := range buffer.ChunkIter(2) {
+			got = append(got, items)
+			break
+		}
+		if want := [][]int{{1, 2}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("yielded chunks: want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestRingBufferAppendTo(t *testing.T) {
+	testCases := []struct {
+		name string
+		dst  []int
+		want []int
+	}{
+		{name: "nil dst", dst: nil, want: []int{3, 4, 5, 6}},
+		{name: "empty dst", dst: []int{}, want: []int{3, 4, 5, 6}},
+		{name: "populated dst", dst: []int{10, 20}, want: []int{10, 20, 3, 4, 5, 6}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5, 6)
+			if got := buffer.AppendTo(tc.dst); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("AppendTo(%v): want %v, got %v", tc.dst, tc.want, got)
+			}
+			if buffer.Size() != 4 {
+				t.Errorf("buffer size: want 4, got %d", buffer.Size())
+			}
+		})
+	}
+
+	t.Run("empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		if got := buffer.AppendTo(nil); got != nil {
+			t.Errorf("AppendTo(nil): want nil, got %v", got)
+		}
+	})
+}
+
+func TestRingBufferCount(t *testing.T) {
+	testCases := []struct {
+		name string
+		pred func(int) bool
+		want int
+	}{
+		{name: "some", pred: func(item int) bool { return item%2 == 0 }, want: 2},
+		{name: "all", pred: func(item int) bool { return item > 0 }, want: 4},
+		{name: "none", pred: func(item int) bool { return item > 6 }, want: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5, 6)
+			if got := buffer.Count(tc.pred); got != tc.want {
+				t.Errorf("Count(): want %d, got %d", tc.want, got)
+			}
+			if buffer.Size() != 4 {
+				t.Errorf("buffer size: want 4, got %d", buffer.Size())
+			}
+		})
+	}
+
+	t.Run("empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 4, 0)
+		if got := buffer.Count(func(int) bool { return true }); got != 0 {
+			t.Errorf("Count(): want 0, got %d", got)
+		}
+	})
+}
+
+func TestRingBufferPeekAt(t *testing.T) {
+	// Wrapped buffer with the logical order [3 4 5 6].
+	buffer := newWrappedBuffer(t, 5, 2, 1, 2, 3, 4, 5, 6)
+
+	testCases := []struct {
+		i      int
+		want   int
+		wantOk bool
+	}{
+		{i: 0, want: 3, wantOk: true},
+		{i: 2, want: 5, wantOk: true},
+		{i: 3, want: 6, wantOk: true},
+		{i: 4},
+		{i: -1, want: 6, wantOk: true},
+		{i: -2, want: 5, wantOk: true},
+		{i: -4, want: 3, wantOk: true},
+		{i: -5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("i: %d", tc.i), func(t *testing.T) {
+			got, ok := buffer.PeekAt(tc.i)
+			if got != tc.want || ok != tc.wantOk {
+				t.Errorf("PeekAt(%d): want %d, %t, got %d, %t", tc.i, tc.want, tc.wantOk, got, ok)
+			}
+		})
+	}
+
+	if buffer.Size() != 4 {
+		t.Errorf("buffer size: want 4, got %d", buffer.Size())
+	}
+}
+
+func TestRingBufferPeekFromNewest(t *testing.T) {
+	// Wrapped buffer with the logical order [3 4 5 6], where 6 is stored at
+	// the beginning of the backing array.
+	buffer := newWrappedBuffer(t, 5, 2, 1, 2, 3, 4, 5, 6)
+
+	testCases := []struct {
+		k      int
+		want   int
+		wantOk bool
+	}{
+		{k: 0, want: 6, wantOk: true},
+		{k: 1, want: 5, wantOk: true},
+		{k: 3, want: 3, wantOk: true},
+		{k: 4},
+		{k: 10},
+		{k: -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("k: %d", tc.k), func(t *testing.T) {
+			got, ok := buffer.PeekFromNewest(tc.k)
+			if got != tc.want || ok != tc.wantOk {
+				t.Errorf("PeekFromNewest(%d): want %d, %t, got %d, %t", tc.k, tc.want, tc.wantOk, got, ok)
+			}
+		})
+	}
+
+	t.Run("empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		if got, ok := buffer.PeekFromNewest(0); ok {
+			t.Errorf("PeekFromNewest(0): want 0, false, got %d, %t", got, ok)
+		}
+	})
+}
+
+func TestRingBufferPeekRange(t *testing.T) {
+	// Wrapped buffer with the logical order [3 4 5 6], where 5 is stored at
+	// the beginning of the data.
+	buffer := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5, 6)
+
+	testCases := []struct {
+		start  int
+		length int
+		want   []int
+	}{
+		{start: 0, length: 2, want: []int{3, 4}},
+		{start: 1, length: 2, want: []int{4, 5}},
+		{start: 1, length: 3, want: []int{4, 5, 6}},
+		{start: 2, length: 10, want: []int{5, 6}},
+		{start: 0, length: 4, want: []int{3, 4, 5, 6}},
+		{start: 3, length: 0, want: []int{}},
+		{start: 1, length: -1, want: []int{}},
+		{start: 4, length: 1, want: []int{}},
+		{start: -1, length: 2, want: []int{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("start: %d, length: %d", tc.start, tc.length), func(t *testing.T) {
+			if got := buffer.PeekRange(tc.start, tc.length); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("PeekRange(%d, %d): want %v, got %v", tc.start, tc.length, tc.want, got)
+			}
+		})
+	}
+	if buffer.Size() != 4 {
+		t.Errorf("buffer size: want 4, got %d", buffer.Size())
+	}
+}
+
+func TestEqual(t *testing.T) {
+	newBuffer := func(capacity, popCount int, items ...string) *ringBuffer[string] {
+		buffer, err := New[string](capacity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < popCount; i++ {
+			buffer.Push("")
+		}
+		for i := 0; i < popCount; i++ {
+			buffer.Pop()
+		}
+		for _, item := range items {
+			buffer.Push(item)
+		}
+		return buffer
+	}
+
+	testCases := []struct {
+		name string
+		a    *ringBuffer[string]
+		b    *ringBuffer[string]
+		want bool
+	}{
+		{
+			name: "empty buffers",
+			a:    newBuffer(1, 0),
+			b:    newBuffer(5, 0),
+			want: true,
+		},
+		{
+			name: "different capacities",
+			a:    newBuffer(3, 0, "apple", "banana", "kiwi"),
+			b:    newBuffer(10, 0, "apple", "banana", "kiwi"),
+			want: true,
+		},
+		{
+			name: "different layouts",
+			a:    newBuffer(4, 3, "apple", "banana", "kiwi"),
+			b:    newBuffer(7, 1, "apple", "banana", "kiwi"),
+			want: true,
+		},
+		{
+			name: "different sizes",
+			a:    newBuffer(4, 0, "apple", "banana"),
+			b:    newBuffer(4, 0, "apple", "banana", "kiwi"),
+			want: false,
+		},
+		{
+			name: "different order",
+			a:    newBuffer(4, 2, "apple", "banana"),
+			b:    newBuffer(4, 0, "banana", "apple"),
+			want: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Equal(tc.a, tc.b); got != tc.want {
+				t.Errorf("Equal(a, b): want %t, got %t", tc.want, got)
+			}
+			if got := Equal(tc.b, tc.a); got != tc.want {
+				t.Errorf("Equal(b, a): want %t, got %t", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("same buffer", func(t *testing.T) {
+		buffer := newBuffer(3, 0, "apple")
+		if !Equal(buffer, buffer) {
+			t.Errorf("buffer should be equal to itself")
+		}
+	})
+
+	t.Run("concurrent comparison", func(t *testing.T) {
+		a := newBuffer(3, 0, "apple")
+		b := newBuffer(3, 0, "apple")
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				Equal(a, b)
+			}()
+			go func() {
+				defer wg.Done()
+				Equal(b, a)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestRingBufferString(t *testing.T) {
+	buffer, err := New[int](4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "[] size=0 cap=4"
+	if got := buffer.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	// Wrap the buffer around, so that the oldest element is the last data cell.
+	for i := 1; i <= 4; i++ {
+		buffer.Push(i)
+	}
+	buffer.Pop()
+	buffer.Pop()
+	buffer.Push(5)
+	buffer.Push(6)
+	buffer.Pop()
+
+	want = "[4 5 6] size=3 cap=4"
+	if got := buffer.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if got := fmt.Sprint(buffer); got != want {
+		t.Errorf("fmt.Sprint: want %q, got %q", want, got)
+	}
+}
+
+func TestRingBufferFingerprint(t *testing.T) {
+	// The same elements with different capacities and layouts.
+	contiguous := newWrappedBuffer(t, 5, 0, 3, 4, 5)
+	wrapped := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5)
+	overwritten := newWrappedBuffer(t, 3, 0, 1, 2, 3, 4, 5)
+	want := contiguous.Fingerprint()
+	for name, buffer := range map[string]*ringBuffer[int]{"wrapped": wrapped, "overwritten": overwritten} {
+		if got := buffer.Fingerprint(); got != want {
+			t.Errorf("%s buffer fingerprint: want %x, got %x", name, want, got)
+		}
+	}
+
+	// Any change of the elements or their order must change the fingerprint.
+	others := map[string]*ringBuffer[int]{
+		"reordered": newWrappedBuffer(t, 3, 0, 3, 5, 4),
+		"shorter":   newWrappedBuffer(t, 3, 0, 3, 4),
+		"longer":    newWrappedBuffer(t, 4, 0, 3, 4, 5, 6),
+		"changed":   newWrappedBuffer(t, 3, 0, 3, 4, 6),
+		"empty":     newWrappedBuffer[int](t, 3, 0),
+	}
+	for name, buffer := range others {
+		if got := buffer.Fingerprint(); got == want {
+			t.Errorf("%s buffer fingerprint: want different from %x", name, want)
+		}
+	}
+
+	t.Run("element boundaries", func(t *testing.T) {
+		a := newWrappedBuffer(t, 2, 0, "ab", "c")
+		b := newWrappedBuffer(t, 2, 0, "a", "bc")
+		if a.Fingerprint() == b.Fingerprint() {
+			t.Errorf("fingerprints of %v and %v must differ", a, b)
+		}
+	})
+
+	t.Run("hash func", func(t *testing.T) {
+		// Hashing only the absolute values makes the signs irrelevant.
+		abs := func(n int) uint64 { return uint64(max(n, -n)) }
+		a := newWrappedBuffer(t, 3, 0, 1, -2, 3)
+		b := newWrappedBuffer(t, 3, 0, -1, 2, -3)
+		if a.FingerprintFunc(abs) != b.FingerprintFunc(abs) {
+			t.Errorf("fingerprints of %v and %v must be equal", a, b)
+		}
+		if a.Fingerprint() == b.Fingerprint() {
+			t.Errorf("fingerprints of %v and %v must differ", a, b)
+		}
+	})
+}
+
+func TestRingBufferResize(t *testing.T) {
+	testCases := []struct {
+		name      string
+		bufCap    int
+		popCount  int
+		pushItems []int
+		newCap    int
+		wantItems []int
+	}{
+		{name: "grow empty", bufCap: 2, newCap: 4, wantItems: []int{}},
+		{name: "grow wrapped", bufCap: 4, popCount: 2, pushItems: []int{1, 2, 3, 4, 5, 6}, newCap: 6, wantItems: []int{3, 4, 5, 6}},
+		{name: "same capacity", bufCap: 3, pushItems: []int{1, 2}, newCap: 3, wantItems: []int{1, 2}},
+		{name: "shrink above size", bufCap: 5, pushItems: []int{1, 2}, newCap: 3, wantItems: []int{1, 2}},
+		{name: "shrink below size", bufCap: 4, popCount: 1, pushItems: []int{1, 2, 3, 4, 5}, newCap: 2, wantItems: []int{4, 5}},
+		{name: "shrink to 1", bufCap: 3, pushItems: []int{1, 2, 3}, newCap: 1, wantItems: []int{3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, tc.bufCap, tc.popCount, tc.pushItems...)
+			if err := buffer.Resize(tc.newCap); err != nil {
+				t.Fatalf("didn't expect an error: %v", err)
+			}
+
+			if buffer.Capacity() != tc.newCap {
+				t.Errorf("buffer capacity: want %d, got %d", tc.newCap, buffer.Capacity())
+			}
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItems, got)
+			}
+			buffer.Pop()
+			buffer.Push(100)
+			if got, _ := buffer.PopNewest(); got != 100 {
+				t.Errorf("PopNewest() item: want 100, got %d", got)
+			}
+		})
+	}
+
+	t.Run("dropped elements", func(t *testing.T) {
+		observer := &countingObserver{}
+		buffer, err := New(4, WithObserver[int](observer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.PushSlice([]int{1, 2, 3, 4})
+		if err := buffer.Resize(2); err != nil {
+			t.Fatal(err)
+		}
+		// The dropped elements must be accounted as overwritten.
+		stats := buffer.StatsSnapshot()
+		if stats.Overwritten != 2 || stats.Pushed-stats.Popped-stats.Overwritten != uint64(stats.Size) {
+			t.Errorf("stats: want 2 overwritten and consistent counters, got %+v", stats)
+		}
+		if buffer.Overflowed() != 2 {
+			t.Errorf("Overflowed(): want 2, got %d", buffer.Overflowed())
+		}
+		if observer.overwrites != 2 {
+			t.Errorf("observer overwrites: want 2, got %d", observer.overwrites)
+		}
+	})
+
+	t.Run("invalid capacity", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 3, 0, 1, 2)
+		if err := buffer.Resize(0); !errors.Is(err, ErrInvalidBuffCap) {
+			t.Errorf("want error: %s, got error: %s", ErrInvalidBuffCap, err)
+		}
+		if buffer.Capacity() != 3 || buffer.Size() != 2 {
+			t.Errorf("buffer should be unchanged on error")
+		}
+	})
+}
+
+func TestRingBufferResizeConcurrent(t *testing.T) {
+	gorAmount := 20
+	opCount := 2000
+	buffer, err := New[int](16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.Push(j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < opCount; j++ {
+				buffer.Pop()
+				buffer.Snapshot()
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	resized := make(chan struct{})
+	go func() {
+		defer close(resized)
+		caps := []int{1, 7, 32, 3, 100, 16}
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				if err := buffer.Resize(caps[i%len(caps)]); err != nil {
+					t.Errorf("didn't expect an error: %v", err)
+				}
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+	<-resized
+
+	size := buffer.Size()
+	if size < 0 || size > buffer.Capacity() {
+		t.Errorf("buffer size %d out of range [0, %d]", size, buffer.Capacity())
+	}
+	if got := len(buffer.Snapshot()); got != size {
+		t.Errorf("snapshot length: want %d, got %d", size, got)
+	}
+	if got := len(buffer.PopAll()); got != size {
+		t.Errorf("popped elements: want %d, got %d", size, got)
+	}
+}
+
+func TestRingBufferGrow(t *testing.T) {
+	testCases := []struct {
+		name       string
+		bufCap     int
+		pushItems  []int
+		popCount   int
+		additional int
+		wantCap    int
+		wantItems  []int
+	}{
+		{
+			name:       "empty buffer",
+			bufCap:     2,
+			additional: 3,
+			wantCap:    5,
+			wantItems:  []int{},
+		},
+		{
+			name:       "full buffer",
+			bufCap:     3,
+			pushItems:  []int{1, 2, 3},
+			additional: 2,
+			wantCap:    5,
+			wantItems:  []int{1, 2, 3},
+		},
+		{
+			name:       "wrapped buffer",
+			bufCap:     4,
+			pushItems:  []int{1, 2, 3, 4, 5, 6},
+			popCount:   2,
+			additional: 4,
+			wantCap:    8,
+			wantItems:  []int{3, 4, 5, 6},
+		},
+		{
+			name:       "zero additional",
+			bufCap:     3,
+			pushItems:  []int{1, 2},
+			additional: 0,
+			wantCap:    3,
+			wantItems:  []int{1, 2},
+		},
+		{
+			name:       "negative additional",
+			bufCap:     3,
+			pushItems:  []int{1, 2},
+			additional: -2,
+			wantCap:    3,
+			wantItems:  []int{1, 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := New[int](tc.bufCap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, item := range tc.pushItems {
+				if i == tc.bufCap {
+					for j := 0; j < tc.popCount; j++ {
+						buffer.Pop()
+					}
+				}
+				buffer.Push(item)
+			}
+
+			buffer.Grow(tc.additional)
+			if buffer.Capacity() != tc.wantCap {
+				t.Errorf("buffer capacity: want %d, got %d", tc.wantCap, buffer.Capacity())
+			}
+			if got := buffer.PeekOldestN(buffer.Size()); !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItems, got)
+			}
+
+			// The new slots must be usable without overwriting.
+			for i := buffer.Size(); i < tc.wantCap; i++ {
+				if err := buffer.TryPush(i); err != nil {
+					t.Errorf("didn't expect an error: %v", err)
+				}
+			}
+			if !buffer.IsFull() {
+				t.Errorf("expected full buffer")
+			}
+			for _, want := range tc.wantItems {
+				if got, _ := buffer.Pop(); got != want {
+					t.Errorf("Pop() item: want %d, got %d", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRingBufferTrim(t *testing.T) {
+	testCases := []struct {
+		name      string
+		bufCap    int
+		pushItems []int
+		popCount  int
+		wantCap   int
+		wantItems []int
+	}{
+		{name: "empty buffer", bufCap: 4, wantCap: 1, wantItems: []int{}},
+		{name: "partially filled", bufCap: 5, pushItems: []int{1, 2, 3}, wantCap: 3, wantItems: []int{1, 2, 3}},
+		{name: "wrapped buffer", bufCap: 4, pushItems: []int{1, 2, 3, 4, 5}, popCount: 2, wantCap: 3, wantItems: []int{3, 4, 5}},
+		{name: "full buffer", bufCap: 3, pushItems: []int{1, 2, 3}, wantCap: 3, wantItems: []int{1, 2, 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := newWrappedBuffer(t, tc.bufCap, tc.popCount, tc.pushItems...)
+			data := buffer.data
+
+			buffer.Trim()
+			if buffer.Capacity() != tc.wantCap {
+				t.Errorf("buffer capacity: want %d, got %d", tc.wantCap, buffer.Capacity())
+			}
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItems, got)
+			}
+			if len(buffer.data) != tc.wantCap {
+				t.Errorf("backing array length: want %d, got %d", tc.wantCap, len(buffer.data))
+			}
+			// No reallocation is expected when the capacity already matches.
+			if tc.bufCap == tc.wantCap && &buffer.data[0] != &data[0] {
+				t.Errorf("expected the backing array to be reused")
+			}
+
+			for _, want := range tc.wantItems {
+				if got, _ := buffer.Pop(); got != want {
+					t.Errorf("Pop() item: want %d, got %d", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	isEven := func(n int) bool { return n%2 == 0 }
+
+	testCases := []struct {
+		name      string
+		bufCap    int
+		pushItems []int
+		popCount  int
+		wantItems []int
+	}{
+		{name: "empty buffer", bufCap: 3, pushItems: []int{}, wantItems: []int{}},
+		{name: "no matches", bufCap: 3, pushItems: []int{1, 3, 5}, wantItems: []int{}},
+		{name: "all match", bufCap: 4, pushItems: []int{2, 4, 6}, wantItems: []int{2, 4, 6}},
+		{
+			name:      "wrapped buffer",
+			bufCap:    5,
+			pushItems: []int{1, 2, 3, 4, 5, 6, 7, 8},
+			popCount:  3,
+			wantItems: []int{4, 6, 8},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := New[int](tc.bufCap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, item := range tc.pushItems {
+				if i == tc.bufCap {
+					for j := 0; j < tc.popCount; j++ {
+						buffer.Pop()
+					}
+				}
+				buffer.Push(item)
+			}
+			before := buffer.String()
+
+			filtered := Filter(buffer, isEven)
+			if filtered.Capacity() != tc.bufCap {
+				t.Errorf("filtered capacity: want %d, got %d", tc.bufCap, filtered.Capacity())
+			}
+			if filtered.Size() != len(tc.wantItems) {
+				t.Errorf("filtered size: want %d, got %d", len(tc.wantItems), filtered.Size())
+			}
+			if got := filtered.PeekOldestN(filtered.Size()); !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("filtered items: want %v, got %v", tc.wantItems, got)
+			}
+			if after := buffer.String(); after != before {
+				t.Errorf("source buffer changed: want %s, got %s", before, after)
+			}
+
+			// The buffers must not share the underlying data.
+			filtered.Push(100)
+			filtered.Pop()
+			if after := buffer.String(); after != before {
+				t.Errorf("source buffer changed: want %s, got %s", before, after)
+			}
+		})
+	}
+}
+
+func TestMap(t *testing.T) {
+	testCases := []struct {
+		name      string
+		bufCap    int
+		pushItems []int
+		popCount  int
+		wantItems []string
+	}{
+		{name: "empty buffer", bufCap: 2, pushItems: []int{}, wantItems: []string{}},
+		{name: "contiguous", bufCap: 4, pushItems: []int{1, 2, 3}, wantItems: []string{"#1", "#2", "#3"}},
+		{
+			name:      "wrapped buffer",
+			bufCap:    3,
+			pushItems: []int{1, 2, 3, 4, 5},
+			popCount:  2,
+			wantItems: []string{"#3", "#4", "#5"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := New[int](tc.bufCap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, item := range tc.pushItems {
+				if i == tc.bufCap {
+					for j := 0; j < tc.popCount; j++ {
+						buffer.Pop()
+					}
+				}
+				buffer.Push(item)
+			}
+
+			mapped := Map(buffer, func(n int) string { return fmt.Sprintf("#%d", n) })
+			if mapped.Capacity() != tc.bufCap {
+				t.Errorf("mapped capacity: want %d, got %d", tc.bufCap, mapped.Capacity())
+			}
+			if mapped.Size() != buffer.Size() {
+				t.Errorf("mapped size: want %d, got %d", buffer.Size(), mapped.Size())
+			}
+			if got := mapped.PeekOldestN(mapped.Size()); !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("mapped items: want %v, got %v", tc.wantItems, got)
+			}
+		})
+	}
+}
+
+func TestRingBufferChannel(t *testing.T) {
+	buffer, err := New[int](5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		buffer.Push(i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := buffer.Channel(ctx)
+
+	for want := 1; want <= 3; want++ {
+		if got := <-ch; got != want {
+			t.Errorf("received item: want %d, got %d", want, got)
+		}
+	}
+
+	// The channel must deliver elements pushed while the goroutine waits.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		buffer.Push(4)
+	}()
+	select {
+	case got := <-ch:
+		if got != 4 {
+			t.Errorf("received item: want 4, got %d", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a pushed item")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Errorf("expected closed channel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel was not closed after cancellation")
+	}
+}
+
+func TestRingBufferPipe(t *testing.T) {
+	t.Run("ordered delivery", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 5, 2, 1, 2, 3, 4, 5, 6, 7)
+		out := make(chan int)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			buffer.Pipe(ctx, out)
+			close(done)
+		}()
+
+		for want := 3; want <= 7; want++ {
+			if got := <-out; got != want {
+				t.Errorf("received item: want %d, got %d", want, got)
+			}
+		}
+		// An element pushed while Pipe waits must be delivered as well.
+		buffer.Push(8)
+		time.Sleep(10 * time.Millisecond)
+		if got := <-out; got != 8 {
+			t.Errorf("received item: want 8, got %d", got)
+		}
+
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Pipe did not return after cancellation")
+		}
+		// The channel is owned by the caller, so it must stay open.
+		select {
+		case item, ok := <-out:
+			t.Errorf("unexpected receive from out: %d, %t", item, ok)
+		default:
+		}
+	})
+
+	t.Run("cancelled while sending", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 3, 0, 1, 2)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		// Nobody receives from out, so Pipe blocks on the first send.
+		buffer.Pipe(ctx, make(chan int))
+		if want := []int{2}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+		}
+	})
+
+	t.Run("closed buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 3, 0, 1)
+		buffer.Close()
+		out := make(chan int, 1)
+		buffer.Pipe(context.Background(), out)
+		if got := <-out; got != 1 {
+			t.Errorf("received item: want 1, got %d", got)
+		}
+	})
+}
+
+func TestRingBufferConsume(t *testing.T) {
+	buffer, err := New[int](5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer.PushSlice([]int{1, 2, 3, 4, 5})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batches := make(chan []int)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buffer.Consume(ctx, 2, func(items []int) { batches <- items })
+	}()
+
+	receive := func() []int {
+		t.Helper()
+		select {
+		case items := <-batches:
+			return items
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a batch")
+			return nil
+		}
+	}
+
+	for _, want := range [][]int{{1, 2}, {3, 4}, {5}} {
+		if got := receive(); !reflect.DeepEqual(got, want) {
+			t.Errorf("batch: want %v, got %v", want, got)
+		}
+	}
+
+	// The loop must wait for elements pushed later.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		buffer.Push(6)
+	}()
+	if got, want := receive(), []int{6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("batch: want %v, got %v", want, got)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Consume didn't return after cancellation")
+	}
+}
+
+func TestIndexOf(t *testing.T) {
+	// Wrapped buffer with the logical order [c d e f g], where "c" is stored
+	// in the middle of the data and "f" at its beginning.
+	buffer := newWrappedBuffer(t, 5, 2, "a", "b", "c", "d", "e", "f", "g")
+
+	testCases := []struct {
+		target string
+		want   int
+	}{
+		{target: "c", want: 0},
+		{target: "e", want: 2},
+		{target: "f", want: 3},
+		{target: "g", want: 4},
+		{target: "a", want: -1},
+		{target: "z", want: -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.target, func(t *testing.T) {
+			if got := IndexOf(buffer, tc.target); got != tc.want {
+				t.Errorf("IndexOf(%q): want %d, got %d", tc.target, tc.want, got)
+			}
+		})
+	}
+
+	t.Run("first match", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 4, 0, 7, 3, 7)
+		if got := IndexOf(buffer, 7); got != 0 {
+			t.Errorf("IndexOf(7): want 0, got %d", got)
+		}
+	})
+}
+
+func TestMinMax(t *testing.T) {
+	testCases := []struct {
+		name    string
+		buffer  *ringBuffer[int]
+		wantMin int
+		wantMax int
+		wantOk  bool
+	}{
+		{name: "empty buffer", buffer: newWrappedBuffer[int](t, 3, 0), wantOk: false},
+		{name: "single element", buffer: newWrappedBuffer(t, 3, 0, -7), wantMin: -7, wantMax: -7, wantOk: true},
+		{name: "negative numbers", buffer: newWrappedBuffer(t, 4, 0, -3, -10, -1, -5), wantMin: -10, wantMax: -1, wantOk: true},
+		{name: "mixed numbers", buffer: newWrappedBuffer(t, 5, 0, 4, -2, 0, 9), wantMin: -2, wantMax: 9, wantOk: true},
+		{
+			// Logical order: [-8 6 20 -30 1], the minimum is stored
+			// before the oldest element.
+			name:    "wrapped buffer",
+			buffer:  newWrappedBuffer(t, 5, 2, 100, -100, -8, 6, 20, -30, 1),
+			wantMin: -30,
+			wantMax: 20,
+			wantOk:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotMin, ok := Min(tc.buffer)
+			if ok != tc.wantOk || gotMin != tc.wantMin {
+				t.Errorf("Min(): want %d, %t, got %d, %t", tc.wantMin, tc.wantOk, gotMin, ok)
+			}
+			gotMax, ok := Max(tc.buffer)
+			if ok != tc.wantOk || gotMax != tc.wantMax {
+				t.Errorf("Max(): want %d, %t, got %d, %t", tc.wantMax, tc.wantOk, gotMax, ok)
+			}
+		})
+	}
+
+	t.Run("strings", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 3, 0, "kiwi", "apple", "mango")
+		if got, _ := Min(buffer); got != "apple" {
+			t.Errorf("Min(): want %q, got %q", "apple", got)
+		}
+		if got, _ := Max(buffer); got != "mango" {
+			t.Errorf("Max(): want %q, got %q", "mango", got)
+		}
+	})
+}
+
+func TestReduce(t *testing.T) {
+	t.Run("sum", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5, 6)
+		got := Reduce(buffer, 0, func(sum, n int) int { return sum + n })
+		if want := 3 + 4 + 5 + 6; got != want {
+			t.Errorf("sum: want %d, got %d", want, got)
+		}
+	})
+
+	t.Run("concatenation", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 3, 1, "a", "b", "c", "d")
+		got := Reduce(buffer, ">", func(acc string, s string) string { return acc + s })
+		if want := ">bcd"; got != want {
+			t.Errorf("concatenation: want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		got := Reduce(buffer, 42, func(acc, n int) int { return acc + n })
+		if got != 42 {
+			t.Errorf("empty buffer: want 42, got %d", got)
+		}
+	})
+}
+
+func TestRecentDistinct(t *testing.T) {
+	// Wrapped buffer with the logical order [b a c a b b].
+	buffer := newWrappedBuffer(t, 6, 2, "x", "y", "b", "a", "c", "a", "b", "b")
+
+	testCases := []struct {
+		n    int
+		want []string
+	}{
+		{n: 0, want: nil},
+		{n: 1, want: []string{"b"}},
+		{n: 2, want: []string{"b", "a"}},
+		{n: 3, want: []string{"b", "a", "c"}},
+		{n: 10, want: []string{"b", "a", "c"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("n: %d", tc.n), func(t *testing.T) {
+			if got := RecentDistinct(buffer, tc.n); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("RecentDistinct(%d): want %v, got %v", tc.n, tc.want, got)
+			}
+		})
+	}
+
+	t.Run("empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		if got := RecentDistinct(buffer, 2); got != nil {
+			t.Errorf("RecentDistinct(2): want nil, got %v", got)
+		}
+	})
+}
+
+func TestDrainGroupBy(t *testing.T) {
+	type event struct {
+		user string
+		id   int
+	}
+	// The buffer wraps around and the oldest events are overwritten.
+	buffer := newWrappedBuffer(t, 5, 1,
+		event{"x", 0}, event{"a", 1}, event{"b", 2}, event{"a", 3}, event{"c", 4},
+		event{"b", 5}, event{"a", 6}, event{"b", 7})
+
+	got := DrainGroupBy(buffer, func(e event) string { return e.user })
+	want := map[string][]event{
+		"a": {{"a", 3}, {"a", 6}},
+		"b": {{"b", 5}, {"b", 7}},
+		"c": {{"c", 4}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groups: want %v, got %v", want, got)
+	}
+	if !buffer.IsEmpty() {
+		t.Errorf("empty buffer expected, got size: %d", buffer.Size())
+	}
+
+	if got := DrainGroupBy(buffer, func(e event) string { return e.user }); len(got) != 0 {
+		t.Errorf("groups of an empty buffer: want none, got %v", got)
+	}
+}
+
+func TestRingBufferWaitUntilFull(t *testing.T) {
+	t.Run("filled by producers", func(t *testing.T) {
+		buffer, err := New[int](10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		done := make(chan error)
+		go func() {
+			done <- buffer.WaitUntilFull(context.Background())
+		}()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				buffer.Push(i)
+				buffer.Push(i)
+			}()
+		}
+		wg.Wait()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("didn't expect an error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("waiter was not woken up after the buffer became full")
+		}
+	})
+
+	t.Run("already full", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 2, 0, 1, 2)
+		if err := buffer.WaitUntilFull(context.Background()); err != nil {
+			t.Errorf("didn't expect an error: %v", err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 3, 0, 1)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := buffer.WaitUntilFull(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("want error: %v, got error: %v", context.DeadlineExceeded, err)
+		}
+	})
+}
+
+func TestRingBufferClose(t *testing.T) {
+	waiters := []struct {
+		name    string
+		items   []int
+		wait    func(buffer *ringBuffer[int]) error
+		wantErr error
+	}{
+		{
+			name:    "WaitUntilFull",
+			items:   []int{1},
+			wait:    func(buffer *ringBuffer[int]) error { return buffer.WaitUntilFull(context.Background()) },
+			wantErr: ErrClosed,
+		},
+		{
+			name:    "PushTimeout",
+			items:   []int{1, 2},
+			wait:    func(buffer *ringBuffer[int]) error { return buffer.PushTimeout(3, time.Minute) },
+			wantErr: ErrClosed,
+		},
+		{
+			name: "PopTimeout",
+			wait: func(buffer *ringBuffer[int]) error {
+				if _, ok := buffer.PopTimeout(time.Minute); ok {
+					return fmt.Errorf("unexpected element")
+				}
+				return nil
+			},
+		},
+		{
+			name: "Channel",
+			wait: func(buffer *ringBuffer[int]) error {
+				for item := range buffer.Channel(context.Background()) {
+					return fmt.Errorf("unexpected element: %d", item)
+				}
+				return nil
+			},
+		},
+	}
 
-	if buffer.Size() != 0 {
-		t.Errorf("buffer size: want 0, got %d", buffer.Size())
+	for _, w := range waiters {
+		t.Run(w.name+" unblocks", func(t *testing.T) {
+			buffer := newWrappedBuffer(t, 2, 0, w.items...)
+			done := make(chan error)
+			go func() {
+				done <- w.wait(buffer)
+			}()
+			time.Sleep(10 * time.Millisecond)
+			buffer.Close()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, w.wantErr) {
+					t.Errorf("want error: %v, got error: %v", w.wantErr, err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("waiter was not woken up after the buffer was closed")
+			}
+		})
 	}
 
-	got, ok := buffer.Get()
-	if got != 0 {
-		t.Errorf("expected empty buffer, got %d", got)
-	}
-	if ok != false {
-		t.Errorf("expected ok: false, got ok: %t", ok)
-	}
+	t.Run("drain", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 3, 0, 1, 2)
+		buffer.Close()
+		buffer.Close() // closing again does nothing
 
-	got, ok = buffer.Pop()
-	if got != 0 {
-		t.Errorf("expected empty buffer, got %d", got)
-	}
-	if ok != false {
-		t.Errorf("expected ok: false, got ok: %t", ok)
-	}
+		if err := buffer.TryPush(3); !errors.Is(err, ErrClosed) {
+			t.Errorf("want error: %v, got error: %v", ErrClosed, err)
+		}
+		if err := buffer.PushValidated(3); !errors.Is(err, ErrClosed) {
+			t.Errorf("PushValidated(): want error: %v, got error: %v", ErrClosed, err)
+		}
+		if n := buffer.Offer([]int{3}); n != 0 {
+			t.Errorf("Offer(): want 0, got %d", n)
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != ErrClosed {
+					t.Errorf("Push() on a closed buffer: want panic %v, got %v", ErrClosed, r)
+				}
+			}()
+			buffer.Push(3)
+		}()
+
+		if item, ok := buffer.PopTimeout(time.Minute); !ok || item != 1 {
+			t.Errorf("PopTimeout(): want 1, true, got %d, %t", item, ok)
+		}
+		if item, ok := buffer.Pop(); !ok || item != 2 {
+			t.Errorf("Pop(): want 2, true, got %d, %t", item, ok)
+		}
+		if item, ok := buffer.PopTimeout(time.Minute); ok {
+			t.Errorf("PopTimeout(): want 0, false, got %d, %t", item, ok)
+		}
+	})
 }
 
-func TestRingBufferDeepClear(t *testing.T) {
-	itemCount := 100
-	buffer, err := New[int](itemCount)
-	if err != nil {
-		t.Fatal(err)
+func TestRingBufferNotFull(t *testing.T) {
+	buffer := newWrappedBuffer(t, 2, 0, 1, 2)
+	notFull := buffer.NotFull()
+
+	// Operations that leave the buffer full must not close the channel.
+	buffer.Push(3)
+	buffer.Get()
+	select {
+	case <-notFull:
+		t.Fatal("channel closed while the buffer is full")
+	default:
 	}
 
-	for i := 0; i < itemCount; i++ {
-		buffer.Push(42)
-	}
-	if buffer.IsEmpty() {
-		t.Errorf("non-empty buffer expected")
+	woken := make(chan struct{})
+	go func() {
+		<-notFull
+		close(woken)
+	}()
+
+	buffer.Pop()
+	select {
+	case <-woken:
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not woken up after Pop")
 	}
 
-	buffer.DeepClear()
-	if !buffer.IsEmpty() {
-		t.Errorf("empty buffer expected")
+	// Each transition uses a new channel.
+	next := buffer.NotFull()
+	if next == notFull {
+		t.Fatal("expected a new channel after the transition")
 	}
-
-	if buffer.Size() != 0 {
-		t.Errorf("buffer size: want 0, got %d", buffer.Size())
+	buffer.Pop()
+	select {
+	case <-next:
+		t.Fatal("channel closed without a transition from full")
+	default:
 	}
 
-	got, ok := buffer.Get()
-	if got != 0 {
-		t.Errorf("expected empty buffer, got %d", got)
+	buffer.Push(4)
+	buffer.Push(5)
+	buffer.Clear()
+	select {
+	case <-next:
+	default:
+		t.Errorf("expected closed channel after clearing a full buffer")
 	}
-	if ok != false {
-		t.Errorf("expected ok: false, got ok: %t", ok)
+}
+
+func TestRingBufferNotEmpty(t *testing.T) {
+	buffer := newWrappedBuffer[int](t, 3, 0)
+	notEmpty := buffer.NotEmpty()
+
+	got := make(chan int)
+	go func() {
+		for {
+			select {
+			case <-notEmpty:
+				item, err := buffer.TryPop()
+				if err == nil {
+					got <- item
+					return
+				}
+				notEmpty = buffer.NotEmpty()
+			case <-time.After(time.Second):
+				close(got)
+				return
+			}
+		}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	buffer.Push(42)
+	if item, ok := <-got; !ok || item != 42 {
+		t.Fatalf("select on NotEmpty: want 42, got %d, %t", item, ok)
 	}
 
-	got, ok = buffer.Pop()
-	if got != 0 {
-		t.Errorf("expected empty buffer, got %d", got)
+	// Pushing to a non-empty buffer isn't a transition.
+	buffer.Push(1)
+	next := buffer.NotEmpty()
+	buffer.Push(2)
+	select {
+	case <-next:
+		t.Fatal("channel closed without a transition from empty")
+	default:
 	}
-	if ok != false {
-		t.Errorf("expected ok: false, got ok: %t", ok)
+
+	buffer.PopAll()
+	buffer.PushFront(3)
+	select {
+	case <-next:
+	default:
+		t.Errorf("expected closed channel after PushFront to an empty buffer")
 	}
 }
 
-func TestRingBufferReuseAfterClear(t *testing.T) {
-	itemCount := 50
-	buffer, err := New[int](itemCount)
+func TestRingBufferHeadSeq(t *testing.T) {
+	buffer, err := New[int](3)
 	if err != nil {
 		t.Fatal(err)
 	}
-	// Initially fill the buffer, just to clear it then.
-	for i := 0; i < itemCount; i++ {
-		buffer.Push(42)
-	}
-	buffer.Clear()
 
-	testNumbers := randomNumbers(itemCount, -100, 100)
-	for _, num := range testNumbers {
-		buffer.Push(num)
-	}
-	if !buffer.IsFull() {
-		t.Errorf("expected full buffer")
-	}
-	gotSize := buffer.Size()
-	if gotSize != len(testNumbers) {
-		t.Errorf("buffer size: got %d, want %d", gotSize, len(testNumbers))
-	}
-	if !reflect.DeepEqual(buffer.data, testNumbers) {
-		t.Errorf("buffer data:\n got %v, \nwant %v", buffer.data, testNumbers)
+	steps := []struct {
+		name string
+		op   func()
+		want uint64
+	}{
+		{name: "new", op: func() {}, want: 1},
+		{name: "push 2", op: func() { buffer.PushSlice([]int{1, 2}) }, want: 1},
+		{name: "pop", op: func() { buffer.Pop() }, want: 2},
+		{name: "push 2", op: func() { buffer.PushSlice([]int{3, 4}) }, want: 2},
+		{name: "overwrite 2", op: func() { buffer.PushSlice([]int{5, 6}) }, want: 4},
+		// 6 had the number 6, so 7 gets 7 rather than taking over 6.
+		{name: "pop newest, push", op: func() { buffer.PopNewest(); buffer.Push(7) }, want: 4},
+		{name: "pop 2", op: func() { buffer.Discard(2) }, want: 7},
+		{name: "push front", op: func() { buffer.PushFront(8) }, want: 8},
+		{name: "pop", op: func() { buffer.Pop() }, want: 7},
+		{name: "clear", op: func() { buffer.Clear() }, want: 9},
+		{name: "push 3", op: func() { buffer.PushSlice([]int{9, 0, 10}) }, want: 9},
+		{name: "reverse", op: func() { buffer.Reverse() }, want: 11},
+		// Compact keeps the numbers of the remaining elements.
+		{name: "compact", op: func() { buffer.Compact(func(n int) bool { return n == 10 }) }, want: 10},
+		{name: "pop after compact", op: func() { buffer.Pop() }, want: 9},
+		{name: "grow", op: func() { buffer.Grow(1) }, want: 9},
+		{name: "pop all", op: func() { buffer.Pop() }, want: 12},
 	}
 
-	idx := 0
-	for !buffer.IsEmpty() {
-		num, _ := buffer.Pop()
-		if num != testNumbers[idx] {
-			t.Errorf("Pop() item: got %d, want %d", num, testNumbers[idx])
+	for _, step := range steps {
+		step.op()
+		if got := buffer.HeadSeq(); got != step.want {
+			t.Errorf("%s: want %d, got %d", step.name, step.want, got)
 		}
-		idx++
 	}
 }
 
-func TestRingBufferIsFull(t *testing.T) {
+func TestRingBufferSegments(t *testing.T) {
 	testCases := []struct {
-		bufferCap int
-		itemCount int
-		want      bool
+		name       string
+		buffer     *ringBuffer[int]
+		discard    int
+		wantFirst  []int
+		wantSecond []int
 	}{
-		{bufferCap: 1, itemCount: 0, want: false},
-		{bufferCap: 1, itemCount: 1, want: true},
-		{bufferCap: 10, itemCount: 9, want: false},
-		{bufferCap: 100, itemCount: 100, want: true},
-		{bufferCap: 120, itemCount: 0, want: false},
-		{bufferCap: 77, itemCount: 290, want: true},
+		{name: "empty", buffer: newWrappedBuffer[int](t, 4, 0), wantFirst: []int{}},
+		{name: "contiguous", buffer: newWrappedBuffer(t, 4, 0, 1, 2, 3), discard: 1, wantFirst: []int{2, 3}},
+		{name: "full", buffer: newWrappedBuffer(t, 4, 0, 1, 2, 3, 4), wantFirst: []int{1, 2, 3, 4}},
+		{name: "ends at array end", buffer: newWrappedBuffer(t, 4, 0, 1, 2, 3, 4), discard: 2, wantFirst: []int{3, 4}},
+		{name: "wrapped", buffer: newWrappedBuffer(t, 4, 2, 1, 2, 3, 4, 5, 6), wantFirst: []int{3, 4}, wantSecond: []int{5, 6}},
+		{name: "overwritten", buffer: newWrappedBuffer(t, 4, 0, 1, 2, 3, 4, 5), wantFirst: []int{2, 3, 4}, wantSecond: []int{5}},
 	}
 
 	for _, tc := range testCases {
-		name := fmt.Sprintf("cap: %d, items: %d", tc.bufferCap, tc.itemCount)
-		t.Run(name, func(t *testing.T) {
-			buffer, err := New[int](tc.bufferCap)
-			if err != nil {
-				t.Fatal(err)
-			}
-
-			for i := 0; i < tc.itemCount; i++ {
-				buffer.Push(1)
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buffer.Discard(tc.discard)
+			first, second := tc.buffer.Segments()
+			if !reflect.DeepEqual(first, tc.wantFirst) || !reflect.DeepEqual(second, tc.wantSecond) {
+				t.Errorf("Segments(): want %v, %v, got %v, %v", tc.wantFirst, tc.wantSecond, first, second)
 			}
-
-			got := buffer.IsFull()
-			if got != tc.want {
-				t.Errorf("want: %t, got: %t", tc.want, got)
+			got := append(append([]int{}, first...), second...)
+			if want := tc.buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+				t.Errorf("concatenated segments: want %v, got %v", want, got)
 			}
 		})
 	}
 }
 
-func TestRingBufferSize(t *testing.T) {
-	testCases := []struct {
-		name      string
-		bufferCap int
-		itemCount int
-		wantSize  int
-	}{
-		{bufferCap: 1, itemCount: 0, wantSize: 0},
-		{bufferCap: 1, itemCount: 1, wantSize: 1},
-		{bufferCap: 10, itemCount: 9, wantSize: 9},
-		{bufferCap: 100, itemCount: 100, wantSize: 100},
-		{bufferCap: 120, itemCount: 0, wantSize: 0},
-		{bufferCap: 77, itemCount: 290, wantSize: 77},
-		{bufferCap: 33, itemCount: 99, wantSize: 33},
+func TestRingBufferDebug(t *testing.T) {
+	buffer, err := New[int](4)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	for _, tc := range testCases {
-		name := fmt.Sprintf("cap: %d, items: %d", tc.bufferCap, tc.itemCount)
-		t.Run(name, func(t *testing.T) {
-			buffer, err := New[bool](tc.bufferCap)
-			if err != nil {
-				t.Fatal(err)
-			}
-			for i := 0; i < tc.itemCount; i++ {
-				buffer.Push(true)
-			}
-
-			if buffer.Size() != tc.wantSize {
-				t.Errorf("size: want %d, got %d", tc.wantSize, buffer.Size())
-			}
-		})
+	type state struct {
+		readerIdx, writerIdx, size, cap int
+		wrapped                         bool
 	}
-}
-
-func TestRingBufferCapacity(t *testing.T) {
-	testCases := []struct {
-		name      string
-		bufferCap int
+	steps := []struct {
+		name string
+		op   func()
+		want state
 	}{
-		{name: "fixed capacity", bufferCap: 4},
-		{name: "random capacity", bufferCap: rand.Intn(1000) + 1},
+		{name: "new", op: func() {}, want: state{0, 0, 0, 4, false}},
+		{name: "push 3", op: func() { buffer.PushSlice([]int{1, 2, 3}) }, want: state{0, 3, 3, 4, false}},
+		{name: "pop 2", op: func() { buffer.Discard(2) }, want: state{2, 3, 1, 4, false}},
+		{name: "push 2", op: func() { buffer.PushSlice([]int{4, 5}) }, want: state{2, 1, 3, 4, true}},
+		{name: "pop 2", op: func() { buffer.Discard(2) }, want: state{0, 1, 1, 4, false}},
+		{name: "clear", op: func() { buffer.Clear() }, want: state{0, 0, 0, 4, false}},
 	}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			buffer, err := New[int](tc.bufferCap)
-			if err != nil {
-				t.Fatal(err)
-			}
-
-			if buffer.Capacity() != tc.bufferCap {
-				t.Errorf("buffer capacity: want %d, got %d", tc.bufferCap, buffer.Capacity())
-			}
-		})
+	for _, step := range steps {
+		step.op()
+		var got state
+		got.readerIdx, got.writerIdx, got.size, got.cap, got.wrapped = buffer.Debug()
+		if got != step.want {
+			t.Errorf("%s: want %+v, got %+v", step.name, step.want, got)
+		}
 	}
 }
 
-func TestRingBufferDetectDataRace(t *testing.T) {
-	bufferCap := 500
-	gorAmount := 100
-	opCount := 10_000
+func TestRingBufferRawData(t *testing.T) {
+	// 1 and 2 are overwritten by 5 and 6, then 3 is popped, which leaves
+	// the cell empty.
+	buffer := newWrappedBuffer(t, 4, 0, 1, 2, 3, 4, 5, 6)
+	buffer.Pop()
 
-	buffer, err := New[string](bufferCap)
-	if err != nil {
-		t.Fatal(err)
+	want := []int{5, 6, 0, 4}
+	got := buffer.RawData()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("raw data: want %v, got %v", want, got)
 	}
-
-	var wg sync.WaitGroup
-	for i := 0; i < gorAmount; i++ {
-		wg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			for j := 0; j < opCount; j++ {
-				item := fmt.Sprintf("item %d from goroutine %d", j, i)
-				buffer.Push(item)
-			}
-		}(i)
+	if want := []int{4, 5, 6}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+		t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
 	}
 
-	for i := 0; i < gorAmount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for j := 0; j < opCount; j++ {
-				buffer.Size()
-			}
-		}()
+	// The returned slice must be a copy.
+	got[0] = 42
+	if buffer.data[0] != 5 {
+		t.Errorf("RawData must return a copy of the backing array")
 	}
+}
 
-	for i := 0; i < gorAmount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for j := 0; j < opCount; j++ {
-				buffer.Capacity()
-			}
-		}()
+func TestRingBufferStatsSnapshot(t *testing.T) {
+	buffer, err := New[int](3)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	for i := 0; i < gorAmount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for j := 0; j < opCount; j++ {
-				buffer.Get()
-			}
-		}()
+	steps := []struct {
+		name string
+		op   func()
+		want Stats
+	}{
+		{name: "new", op: func() {}, want: Stats{Capacity: 3}},
+		{name: "push 2", op: func() { buffer.PushSlice([]int{1, 2}) }, want: Stats{Size: 2, Capacity: 3, Pushed: 2}},
+		{name: "push 3", op: func() { buffer.PushSlice([]int{3, 4, 5}) }, want: Stats{Size: 3, Capacity: 3, Pushed: 5, Overwritten: 2}},
+		{name: "pop 2", op: func() { buffer.Discard(2) }, want: Stats{Size: 1, Capacity: 3, Pushed: 5, Popped: 2, Overwritten: 2}},
+		{name: "push front", op: func() { buffer.PushFront(6) }, want: Stats{Size: 2, Capacity: 3, Pushed: 6, Popped: 2, Overwritten: 2}},
+		{name: "pop newest", op: func() { buffer.PopNewest() }, want: Stats{Size: 1, Capacity: 3, Pushed: 6, Popped: 3, Overwritten: 2}},
+		{name: "pop empty", op: func() { buffer.PopAll(); buffer.Pop() }, want: Stats{Capacity: 3, Pushed: 6, Popped: 4, Overwritten: 2}},
+		{name: "grow", op: func() { buffer.Grow(2) }, want: Stats{Capacity: 5, Pushed: 6, Popped: 4, Overwritten: 2}},
 	}
 
-	for i := 0; i < gorAmount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for j := 0; j < opCount; j++ {
-				buffer.Pop()
-			}
-		}()
+	for _, step := range steps {
+		step.op()
+		if got := buffer.StatsSnapshot(); got != step.want {
+			t.Errorf("%s: want %+v, got %+v", step.name, step.want, got)
+		}
 	}
+}
 
-	for i := 0; i < gorAmount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for j := 0; j < opCount; j++ {
-				buffer.IsEmpty()
-			}
-		}()
+func TestRingBufferOverflowed(t *testing.T) {
+	buffer, err := New[int](3)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	for i := 0; i < gorAmount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for j := 0; j < opCount; j++ {
-				buffer.IsFull()
-			}
-		}()
+	steps := []struct {
+		name string
+		op   func()
+		want uint64
+	}{
+		{name: "fill", op: func() { buffer.PushSlice([]int{1, 2, 3}) }, want: 0},
+		{name: "overflow 2", op: func() { buffer.PushSlice([]int{4, 5}) }, want: 2},
+		{name: "overflow 3", op: func() { buffer.PushSlice([]int{6, 7, 8}) }, want: 5},
+		{name: "reset", op: buffer.ResetOverflowed, want: 0},
+		{name: "pop and push", op: func() { buffer.Pop(); buffer.Push(9) }, want: 0},
+		{name: "push front", op: func() { buffer.PushFront(10) }, want: 1},
+		{name: "overflow 1", op: func() { buffer.Push(11) }, want: 2},
 	}
 
-	wg.Wait()
+	for _, step := range steps {
+		step.op()
+		if got := buffer.Overflowed(); got != step.want {
+			t.Errorf("%s: want %d, got %d", step.name, step.want, got)
+		}
+	}
+	// The lifetime counter must not be reset.
+	if got := buffer.StatsSnapshot().Overwritten; got != 7 {
+		t.Errorf("overwritten elements: want 7, got %d", got)
+	}
 }
 
 // randomNumbers returns a slice of size random integers
@@ -751,3 +3890,24 @@ func randomNumbers(size, min, max int) []int {
 
 	return numbers
 }
+
+// newWrappedBuffer returns a buffer with the given capacity filled with the
+// given items. Once the buffer is full, popCount elements are popped before
+// the remaining items are pushed, so the buffer wraps around without
+// overwriting any element.
+func newWrappedBuffer[T any](t testing.TB, capacity, popCount int, items ...T) *ringBuffer[T] {
+	t.Helper()
+	buffer, err := New[T](capacity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, item := range items {
+		if i == capacity {
+			for j := 0; j < popCount; j++ {
+				buffer.Pop()
+			}
+		}
+		buffer.Push(item)
+	}
+	return buffer
+}
@@ -0,0 +1,185 @@
+package buffer
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+var ErrUnsupportedType = fmt.Errorf("element type is neither fixed-size nor a BinaryMarshaler")
+var ErrInvalidEncoding = fmt.Errorf("invalid binary encoding of the buffer")
+
+// binaryVersion is the version of the binary encoding written to the header.
+const binaryVersion = 1
+
+// maxDecodedBytes limits the size of the backing array allocated by
+// UnmarshalBinary, since the capacity comes from the possibly untrusted input.
+const maxDecodedBytes = 1 << 32
+
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding consists of
+// a version byte, the capacity and the size of the buffer, followed by the
+// elements from the oldest to the newest.
+//
+// Fixed-size element types, such as byte, the sized numbers like int32 or
+// float64, and arrays or structs of them, are encoded as by encoding/binary in
+// little-endian order. Other types must implement encoding.BinaryMarshaler,
+// otherwise ErrUnsupportedType is returned.
+func (rb *ringBuffer[T]) MarshalBinary() ([]byte, error) {
+	rb.mu.RLock()
+	capacity := int(rb.cap.Load())
+	items := rb.copyRange(0, int(rb.size.Load()))
+	rb.mu.RUnlock()
+
+	data := []byte{binaryVersion}
+	data = binary.AppendUvarint(data, uint64(capacity))
+	data = binary.AppendUvarint(data, uint64(len(items)))
+	if binary.Size(items) >= 0 {
+		return binary.Append(data, binary.LittleEndian, items)
+	}
+
+	for _, item := range items {
+		m, ok := any(item).(encoding.BinaryMarshaler)
+		if !ok {
+			return nil, ErrUnsupportedType
+		}
+		b, err := m.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = binary.AppendUvarint(data, uint64(len(b)))
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It replaces the
+// contents and the capacity of the buffer with the ones decoded from data,
+// which must be produced by MarshalBinary. Element types that are not
+// fixed-size must be decoded by their pointer implementing
+// encoding.BinaryUnmarshaler, otherwise ErrUnsupportedType is returned.
+// Malformed data, or data declaring a capacity whose backing array would
+// exceed 4 GiB, returns ErrInvalidEncoding.
+// The buffer must be created by New.
+func (rb *ringBuffer[T]) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != binaryVersion {
+		return ErrInvalidEncoding
+	}
+	data = data[1:]
+	capacity, n := binary.Uvarint(data)
+	if n <= 0 {
+		return ErrInvalidEncoding
+	}
+	data = data[n:]
+	size, n := binary.Uvarint(data)
+	if n <= 0 || capacity < 1 || size > capacity {
+		return ErrInvalidEncoding
+	}
+	var zero T
+	if capacity > maxDecodedBytes/max(uint64(unsafe.Sizeof(zero)), 1) {
+		return ErrInvalidEncoding
+	}
+	data = data[n:]
+
+	items, err := decodeItems[T](data, size)
+	if err != nil {
+		return err
+	}
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	wasEmpty := rb.size.Load() == 0
+	newCap := rb.roundCap(int(capacity))
+	if rb.isFull() && len(items) < newCap {
+		rb.wakeNotFull()
+	}
+	rb.data = make([]T, newCap)
+	copy(rb.data, items)
+	rb.cap.Store(int64(newCap))
+	rb.shrunkFrom = 0
+	rb.size.Store(int64(len(items)))
+	rb.seq += uint64(len(items))
+	rb.seqs = nil
+	rb.pos += uint64(len(items))
+	rb.readerIdx = 0
+	rb.writerIdx = len(items) % newCap
+	rb.lastWriterIdx = max(len(items)-1, 0)
+	rb.wrapped = len(items) == newCap && !rb.noWrap
+	if len(items) > 0 {
+		rb.wakeNotEmpty(wasEmpty)
+	}
+	rb.wakeFull()
+	return nil
+}
+
+// EncodeJSONStream writes the elements to w as a JSON array, from the oldest
+// to the newest. Each element is encoded directly to w by a json.Encoder, so
+// unlike marshaling a Snapshot, no intermediate copy of the elements is made.
+// The read lock is held until the array is written, so writers are blocked
+// while w is written to. Returns the first error of encoding or writing, in
+// which case w may hold an incomplete array.
+func (rb *ringBuffer[T]) EncodeJSONStream(w io.Writer) error {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for i := 0; i < int(rb.size.Load()); i++ {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(rb.data[rb.physIdx(i)]); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
+// decodeItems decodes size elements encoded by MarshalBinary. All of data must
+// be consumed. The size is checked against the length of data before the
+// elements are allocated, since it comes from the possibly untrusted input.
+func decodeItems[T any](data []byte, size uint64) ([]T, error) {
+	var zero T
+	if itemSize := binary.Size(zero); itemSize >= 0 {
+		if itemSize > 0 && size != uint64(len(data))/uint64(itemSize) {
+			return nil, ErrInvalidEncoding
+		}
+		items := make([]T, size)
+		n, err := binary.Decode(data, binary.LittleEndian, items)
+		if err != nil || n != len(data) {
+			return nil, ErrInvalidEncoding
+		}
+		return items, nil
+	}
+
+	// Each element takes at least the byte of its length prefix.
+	if size > uint64(len(data)) {
+		return nil, ErrInvalidEncoding
+	}
+	items := make([]T, size)
+	for i := range items {
+		u, ok := any(&items[i]).(encoding.BinaryUnmarshaler)
+		if !ok {
+			return nil, ErrUnsupportedType
+		}
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length > uint64(len(data)-n) {
+			return nil, ErrInvalidEncoding
+		}
+		data = data[n:]
+		if err := u.UnmarshalBinary(data[:length]); err != nil {
+			return nil, err
+		}
+		data = data[length:]
+	}
+	if len(data) != 0 {
+		return nil, ErrInvalidEncoding
+	}
+	return items, nil
+}
@@ -0,0 +1,230 @@
+package buffer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRingBufferMarshalBinary(t *testing.T) {
+	t.Run("byte buffer", func(t *testing.T) {
+		src := newWrappedBuffer(t, 5, 2, []byte("abcdefg")...)
+		data, err := src.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dst, err := New[byte](1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := dst.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if dst.Capacity() != 5 {
+			t.Errorf("buffer capacity: want 5, got %d", dst.Capacity())
+		}
+		if !Equal(src, dst) {
+			t.Errorf("buffer items: want %q, got %q", src.Snapshot(), dst.Snapshot())
+		}
+
+		// The buffer must continue from the decoded state.
+		dst.Pop()
+		dst.Push('h')
+		if want := []byte("defgh"); !reflect.DeepEqual(dst.Snapshot(), want) {
+			t.Errorf("buffer items: want %q, got %q", want, dst.Snapshot())
+		}
+	})
+
+	t.Run("empty buffer", func(t *testing.T) {
+		src := newWrappedBuffer[int32](t, 3, 0)
+		data, err := src.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := newWrappedBuffer[int32](t, 1, 0, 1)
+		if err := dst.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if !dst.IsEmpty() || dst.Capacity() != 3 {
+			t.Errorf("want empty buffer with capacity 3, got size %d, capacity %d", dst.Size(), dst.Capacity())
+		}
+	})
+
+	t.Run("binary marshaler elements", func(t *testing.T) {
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		src := newWrappedBuffer(t, 3, 0, start, start.Add(time.Hour))
+		data, err := src.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dst, err := New[time.Time](1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := dst.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		got, want := dst.Snapshot(), src.Snapshot()
+		if len(got) != len(want) {
+			t.Fatalf("buffer size: want %d, got %d", len(want), len(got))
+		}
+		for i := range want {
+			if !got[i].Equal(want[i]) {
+				t.Errorf("item %d: want %v, got %v", i, want[i], got[i])
+			}
+		}
+	})
+
+	t.Run("pow2 capacity", func(t *testing.T) {
+		data, err := newWrappedBuffer[int32](t, 3, 0, 1, 2, 3).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst, err := New(1, WithPow2Capacity[int32]())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := dst.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if dst.Capacity() != 4 {
+			t.Errorf("buffer capacity: want 4, got %d", dst.Capacity())
+		}
+		dst.PushSlice([]int32{4, 5})
+		if want := []int32{2, 3, 4, 5}; !reflect.DeepEqual(dst.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, dst.Snapshot())
+		}
+	})
+
+	t.Run("sequence numbers", func(t *testing.T) {
+		data, err := newWrappedBuffer[int32](t, 3, 0, 7, 8, 9).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := newWrappedBuffer[int32](t, 3, 0, 1, 2)
+		sub := dst.Subscribe()
+		defer sub.Close()
+		if err := dst.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if got := dst.HeadSeq(); got != 3 {
+			t.Errorf("HeadSeq(): want 3, got %d", got)
+		}
+		// The subscription must skip the replaced elements.
+		if item, ok := sub.Next(context.Background()); !ok || item != 7 {
+			t.Errorf("Next(): want 7, true, got %d, %t", item, ok)
+		}
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		src := newWrappedBuffer(t, 3, 0, "a")
+		if _, err := src.MarshalBinary(); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("want error: %v, got error: %v", ErrUnsupportedType, err)
+		}
+	})
+
+	t.Run("invalid encoding", func(t *testing.T) {
+		valid, err := newWrappedBuffer[byte](t, 3, 0, 1, 2).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testCases := []struct {
+			name string
+			data []byte
+		}{
+			{name: "empty", data: nil},
+			{name: "wrong version", data: append([]byte{0}, valid[1:]...)},
+			{name: "zero capacity", data: []byte{binaryVersion, 0, 0}},
+			{name: "size exceeds capacity", data: []byte{binaryVersion, 1, 2, 1, 2}},
+			{name: "huge capacity", data: binary.AppendUvarint([]byte{binaryVersion}, 1<<62)},
+			{name: "capacity overflows int", data: binary.AppendUvarint([]byte{binaryVersion}, 1<<63)},
+			{name: "truncated", data: valid[:len(valid)-1]},
+			{name: "trailing bytes", data: append(valid, 3)},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				dst := newWrappedBuffer[byte](t, 2, 0, 7)
+				if err := dst.UnmarshalBinary(tc.data); !errors.Is(err, ErrInvalidEncoding) {
+					t.Errorf("want error: %v, got error: %v", ErrInvalidEncoding, err)
+				}
+				// The buffer must stay untouched.
+				if want := []byte{7}; !reflect.DeepEqual(dst.Snapshot(), want) {
+					t.Errorf("buffer items: want %v, got %v", want, dst.Snapshot())
+				}
+			})
+		}
+	})
+}
+
+func TestRingBufferUnmarshalBinaryBogusSize(t *testing.T) {
+	// The header declares 1<<27 elements, but no payload follows. The
+	// elements must not be allocated before the size is checked.
+	data := binary.AppendUvarint([]byte{binaryVersion}, 1<<27)
+	data = binary.AppendUvarint(data, 1<<27)
+
+	bytesBuf := newWrappedBuffer[byte](t, 1, 0)
+	timesBuf := newWrappedBuffer[time.Time](t, 1, 0)
+	allocs := testing.AllocsPerRun(10, func() {
+		if err := bytesBuf.UnmarshalBinary(data); !errors.Is(err, ErrInvalidEncoding) {
+			t.Errorf("byte buffer: want error: %v, got error: %v", ErrInvalidEncoding, err)
+		}
+		if err := timesBuf.UnmarshalBinary(data); !errors.Is(err, ErrInvalidEncoding) {
+			t.Errorf("time buffer: want error: %v, got error: %v", ErrInvalidEncoding, err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("allocations: want 0, got %v", allocs)
+	}
+}
+
+func TestRingBufferEncodeJSONStream(t *testing.T) {
+	type point struct {
+		X, Y int
+		Tag  string
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		items := []point{{1, 2, "a"}, {3, 4, "b"}, {5, 6, "c"}, {7, 8, "d"}, {9, 10, "e"}}
+		buffer := newWrappedBuffer(t, 4, 2, items...)
+		var buf bytes.Buffer
+		if err := buffer.EncodeJSONStream(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var got []point
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("decoding %q: %v", buf.String(), err)
+		}
+		if want := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("decoded items: want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("empty buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[point](t, 4, 0)
+		var buf bytes.Buffer
+		if err := buffer.EncodeJSONStream(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != "[]" {
+			t.Errorf("encoded buffer: want %q, got %q", "[]", buf.String())
+		}
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 4, 0, make(chan int))
+		var buf bytes.Buffer
+		var typeErr *json.UnsupportedTypeError
+		if err := buffer.EncodeJSONStream(&buf); !errors.As(err, &typeErr) {
+			t.Errorf("want error: %T, got error: %v", typeErr, err)
+		}
+	})
+}
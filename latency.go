@@ -0,0 +1,105 @@
+package buffer
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// latencySubBits is the number of bits used for the linear sub-buckets within
+// each power of two, which bounds the relative error of the recorded values
+// by 1/2^latencySubBits.
+const latencySubBits = 3
+const latencySubBuckets = 1 << latencySubBits
+
+// latencyHistogram is a lock-free histogram of durations in the spirit of HDR
+// histograms: the values are grouped by powers of two, each of which is split
+// into latencySubBuckets linear sub-buckets.
+type latencyHistogram struct {
+	counts [(64 - latencySubBits + 1) * latencySubBuckets]atomic.Uint64
+}
+
+// record adds a duration to the histogram. Negative durations are recorded
+// as zero.
+func (h *latencyHistogram) record(d time.Duration) {
+	h.counts[latencyBucket(uint64(max(d, 0)))].Add(1)
+}
+
+// percentile returns the duration below or equal to which p percent of the
+// recorded durations fall, rounded up to the bucket boundary. Returns 0 if
+// nothing was recorded.
+func (h *latencyHistogram) percentile(p float64) time.Duration {
+	var total uint64
+	for i := range h.counts {
+		total += h.counts[i].Load()
+	}
+	if total == 0 {
+		return 0
+	}
+
+	p = min(max(p, 0), 100)
+	rank := max(uint64(math.Ceil(p/100*float64(total))), 1)
+	var seen uint64
+	for i := range h.counts {
+		seen += h.counts[i].Load()
+		if seen >= rank {
+			return time.Duration(latencyBucketMax(i))
+		}
+	}
+	// The counts were updated concurrently, fall back to the last bucket.
+	return time.Duration(latencyBucketMax(len(h.counts) - 1))
+}
+
+// latencyBucket returns the histogram bucket index of the value in
+// nanoseconds.
+func latencyBucket(ns uint64) int {
+	if ns < latencySubBuckets {
+		return int(ns)
+	}
+	exp := bits.Len64(ns) - 1
+	sub := (ns >> (exp - latencySubBits)) & (latencySubBuckets - 1)
+	return (exp-latencySubBits+1)*latencySubBuckets + int(sub)
+}
+
+// latencyBucketMax returns the highest value in nanoseconds that falls into
+// the histogram bucket with the given index.
+func latencyBucketMax(idx int) uint64 {
+	if idx < latencySubBuckets {
+		return uint64(idx)
+	}
+	exp := idx/latencySubBuckets + latencySubBits - 1
+	sub := uint64(idx % latencySubBuckets)
+	width := uint64(1) << (exp - latencySubBits)
+	return (latencySubBuckets+sub)*width + width - 1
+}
+
+// latencyRecorder holds the latency histograms of the buffer operations,
+// enabled by WithLatencyHistogram.
+type latencyRecorder struct {
+	push latencyHistogram
+	pop  latencyHistogram
+}
+
+// observeLatency records the time elapsed since start in the histogram.
+func (rb *ringBuffer[T]) observeLatency(h *latencyHistogram, start time.Time) {
+	h.record(rb.now().Sub(start))
+}
+
+// LatencyPercentile returns the duration below or equal to which p percent
+// of the recorded durations of the operation fall, e.g. p = 99 for the 99th
+// percentile. The supported operations are "push" and "pop". The result is
+// accurate to about 12.5%. Returns 0 if the buffer wasn't created
+// WithLatencyHistogram, the operation is unknown, or nothing was recorded.
+func (rb *ringBuffer[T]) LatencyPercentile(op string, p float64) time.Duration {
+	if rb.latency == nil {
+		return 0
+	}
+	switch op {
+	case "push":
+		return rb.latency.push.percentile(p)
+	case "pop":
+		return rb.latency.pop.percentile(p)
+	}
+	return 0
+}
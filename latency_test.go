@@ -0,0 +1,105 @@
+package buffer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	var h latencyHistogram
+	if got := h.percentile(50); got != 0 {
+		t.Errorf("empty histogram: want 0, got %v", got)
+	}
+
+	for i := 1; i <= 100; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+
+	testCases := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0, want: time.Microsecond},
+		{p: 50, want: 50 * time.Microsecond},
+		{p: 90, want: 90 * time.Microsecond},
+		{p: 99, want: 99 * time.Microsecond},
+		{p: 100, want: 100 * time.Microsecond},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("p%v", tc.p), func(t *testing.T) {
+			got := h.percentile(tc.p)
+			// The result is rounded up to the bucket boundary.
+			if got < tc.want || float64(got) > float64(tc.want)*1.125 {
+				t.Errorf("percentile(%v): want %v within 12.5%%, got %v", tc.p, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLatencyBucket(t *testing.T) {
+	// Every value must fall into a bucket whose bounds contain it, and the
+	// buckets must not overlap.
+	prevMax := -1
+	for _, ns := range []uint64{0, 1, 7, 8, 15, 16, 17, 100, 1 << 20, 1<<40 + 12345, 1<<63 + 1} {
+		idx := latencyBucket(ns)
+		if upper := latencyBucketMax(idx); upper < ns {
+			t.Errorf("value %d: bucket %d max %d is less than the value", ns, idx, upper)
+		}
+		if idx > 0 && latencyBucketMax(idx-1) >= ns {
+			t.Errorf("value %d: previous bucket %d contains the value", ns, idx-1)
+		}
+		if idx < prevMax {
+			t.Errorf("value %d: bucket %d precedes the bucket of a smaller value", ns, idx)
+		}
+		prevMax = idx
+	}
+}
+
+func TestWithLatencyHistogram(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		buffer, err := New[int](3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.Push(1)
+		buffer.Pop()
+		if got := buffer.LatencyPercentile("push", 50); got != 0 {
+			t.Errorf("push latency: want 0, got %v", got)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock := &fakeClock{now: start}
+		// Each reading of the clock advances it, so every operation takes
+		// exactly one step.
+		step := 10 * time.Microsecond
+		now := func() time.Time {
+			current := clock.Now()
+			clock.Advance(step)
+			return current
+		}
+		buffer, err := New(3, WithLatencyHistogram[int](), WithClock[int](now))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 5; i++ {
+			buffer.Push(i)
+		}
+		buffer.Pop()
+		buffer.Pop()
+
+		for _, op := range []string{"push", "pop"} {
+			got := buffer.LatencyPercentile(op, 99)
+			if got < step || float64(got) > float64(step)*1.125 {
+				t.Errorf("%s latency: want %v within 12.5%%, got %v", op, step, got)
+			}
+		}
+		if got := buffer.LatencyPercentile("peek", 99); got != 0 {
+			t.Errorf("unknown operation latency: want 0, got %v", got)
+		}
+	})
+}
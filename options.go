@@ -0,0 +1,258 @@
+package buffer
+
+import (
+	"math"
+	"reflect"
+	"time"
+)
+
+// Option configures a ring buffer created by New.
+type Option[T any] func(*options[T])
+
+// options holds the configuration collected from the options passed to New.
+type options[T any] struct {
+	observer    Observer
+	initialData []T
+	now         func() time.Time
+	fillSamples int
+	noWrap      bool
+	maxCap      int
+	pow2        bool
+	dedup       func(a, b T) bool
+	latency     bool
+	flush       func([]T)
+	noZeroOnPop bool
+	validate    func(T) error
+	shrink      float64
+	block       bool
+	tee         *ringBuffer[T]
+	defaultCap  int
+}
+
+// Observer receives notifications about buffer operations, e.g. to export
+// metrics. The methods are called after the buffer lock is released, so they
+// may be called concurrently and must be safe for concurrent use.
+type Observer interface {
+	// OnPush is called after an element is added to the buffer.
+	OnPush()
+	// OnPop is called after an element is removed from the buffer.
+	OnPop()
+	// OnOverwrite is called after a Push overwrites the oldest element.
+	OnOverwrite()
+	// OnFull is called after a Push fills the last free slot of the buffer.
+	OnFull()
+}
+
+// ResizeObserver is an optional extension of Observer. If the observer set by
+// WithObserver also implements it, it's notified when the buffer capacity
+// changes, either explicitly, e.g. by Resize, Grow, Trim or Reset, or
+// automatically by WithAutoGrow. It's called after the buffer lock is
+// released.
+type ResizeObserver interface {
+	OnResize(oldCap, newCap int)
+}
+
+// WithObserver sets an observer that is notified about buffer operations.
+// A nil observer, including a nil pointer of a type implementing Observer,
+// disables the notifications.
+func WithObserver[T any](o Observer) Option[T] {
+	return func(opts *options[T]) {
+		if isNil(o) {
+			o = nil
+		}
+		opts.observer = o
+	}
+}
+
+// WithInitialData prefills the buffer with the given items, as if they were
+// pushed one by one, so the other options apply to them. With
+// WithOverflow(Block), the items that don't fit are dropped. The observer
+// isn't notified, and WithFlushOnFull doesn't flush the buffer.
+func WithInitialData[T any](items []T) Option[T] {
+	return func(opts *options[T]) {
+		opts.initialData = items
+	}
+}
+
+// WithClock sets the function the buffer uses to get the current time instead
+// of time.Now. It's mostly useful to control time-dependent behavior, such as
+// element expiry, in tests.
+func WithClock[T any](now func() time.Time) Option[T] {
+	return func(opts *options[T]) {
+		opts.now = now
+	}
+}
+
+// WithFillSampler enables recording of the buffer size after each push and
+// pop operation. The last n sizes are kept and can be retrieved with
+// FillSamples. If n is not positive, sampling stays disabled.
+func WithFillSampler[T any](n int) Option[T] {
+	return func(opts *options[T]) {
+		opts.fillSamples = n
+	}
+}
+
+// WithNoWrap makes the buffer stop accepting new elements once it's full,
+// instead of overwriting the oldest ones. Push, PushFront and PushSlice drop
+// the elements that don't fit until space is freed by Pop or Clear. The
+// writer never wraps around ahead of the reader, as reported by Debug.
+func WithNoWrap[T any]() Option[T] {
+	return func(opts *options[T]) {
+		opts.noWrap = true
+	}
+}
+
+// OverflowStrategy determines what the push methods do when the buffer is
+// full. It's set by WithOverflow.
+type OverflowStrategy int
+
+const (
+	// OverwriteOldest makes the pushed element overwrite the oldest one. It's
+	// the default strategy.
+	OverwriteOldest OverflowStrategy = iota
+	// RejectNew makes the push methods drop the pushed element, like
+	// WithNoWrap.
+	RejectNew
+	// Grow makes the buffer double its capacity, like WithAutoGrow without a
+	// limit.
+	Grow
+	// Block makes Push, PushFront and PushSlice wait until an element is
+	// removed, e.g. by Pop. A push waiting on a buffer that gets closed
+	// panics with ErrClosed.
+	Block
+)
+
+// WithOverflow sets the strategy of the push methods for a full buffer. It
+// replaces the effect of WithNoWrap and WithAutoGrow passed before it. The
+// methods that never overwrite, such as TryPush or Offer, don't depend on the
+// strategy, and Move stops when dst is full with Block, as with RejectNew.
+func WithOverflow[T any](strategy OverflowStrategy) Option[T] {
+	return func(opts *options[T]) {
+		opts.noWrap = strategy == RejectNew
+		opts.block = strategy == Block
+		opts.maxCap = 0
+		if strategy == Grow {
+			opts.maxCap = math.MaxInt
+		}
+	}
+}
+
+// WithAutoGrow makes a full buffer double its capacity on push instead of
+// overwriting the oldest element, until the capacity reaches maxCap. After
+// that, the buffer overwrites as usual. The doubled capacity is limited by
+// maxCap, and if maxCap doesn't exceed the initial capacity, the buffer never
+// grows.
+func WithAutoGrow[T any](maxCap int) Option[T] {
+	return func(opts *options[T]) {
+		opts.maxCap = maxCap
+	}
+}
+
+// WithPow2Capacity rounds the buffer capacity up to the next power of two, so
+// the indices can wrap around with a bitmask instead of an integer division.
+// Capacity reports the rounded value. The capacities set later by Reset,
+// Resize, Grow, Trim or WithAutoGrow are rounded up as well.
+func WithPow2Capacity[T any]() Option[T] {
+	return func(opts *options[T]) {
+		opts.pow2 = true
+	}
+}
+
+// WithDedup makes the push methods skip an element if eq reports that it's
+// equal to the newest element in the buffer, which collapses runs of
+// identical elements into one. Equal elements that aren't adjacent are kept.
+// PushFront, which adds at the other end, doesn't skip any elements.
+func WithDedup[T any](eq func(a, b T) bool) Option[T] {
+	return func(opts *options[T]) {
+		opts.dedup = eq
+	}
+}
+
+// WithLatencyHistogram enables recording of the Push and Pop durations into
+// histograms, which can be queried with LatencyPercentile. The durations are
+// measured with the clock set by WithClock. Without this option, the
+// operations aren't timed at all.
+func WithLatencyHistogram[T any]() Option[T] {
+	return func(opts *options[T]) {
+		opts.latency = true
+	}
+}
+
+// WithFlushOnFull makes the buffer pass all its elements, ordered from the
+// oldest to the newest, to fn when a push method fills the last free slot,
+// and then clear itself. This way, the buffer collects elements
+// into batches of its capacity. fn is called after the lock is released.
+func WithFlushOnFull[T any](fn func([]T)) Option[T] {
+	return func(opts *options[T]) {
+		opts.flush = fn
+	}
+}
+
+// WithZeroOnPop sets whether Pop and the other removing methods write the
+// zero value to the vacated cells, which is the default. Zeroing lets the
+// garbage collector reclaim the memory referenced by the removed elements, but
+// for value types, such as numbers, it's unnecessary work. With zero set to
+// false, the vacated cells keep stale elements until they're overwritten.
+// They are never returned by the buffer methods, except RawData.
+func WithZeroOnPop[T any](zero bool) Option[T] {
+	return func(opts *options[T]) {
+		opts.noZeroOnPop = !zero
+	}
+}
+
+// WithValidator sets a function that checks the elements before any method
+// adds them to the buffer. If fn returns an error, the element isn't added.
+// PushValidated, TryPush and TryPushBatch return the error, Push, PushFront
+// and PushPop drop the element silently, and the batch methods, such as
+// PushSlice or Offer, skip it, except Move, which stops at it. fn may be
+// called with the buffer lock held, so it must not use the buffer.
+func WithValidator[T any](fn func(T) error) Option[T] {
+	return func(opts *options[T]) {
+		opts.validate = fn
+	}
+}
+
+// WithShrinkPolicy makes the buffer release memory when it stays mostly
+// empty. If Pop leaves the buffer filled below minLoadFactor of its capacity,
+// e.g. 0.25 for a quarter, for 16 consecutive pops, the capacity is halved,
+// but not below the buffer size. A shrunk buffer grows back on push, as with
+// WithAutoGrow, up to the capacity it had before shrinking. If minLoadFactor
+// isn't positive, the buffer never shrinks.
+func WithShrinkPolicy[T any](minLoadFactor float64) Option[T] {
+	return func(opts *options[T]) {
+		opts.shrink = minLoadFactor
+	}
+}
+
+// WithTee makes the buffer also push every element added to it into other,
+// e.g. to keep an audit log in a larger buffer. The elements are pushed as by
+// PushSlice after the buffer lock is released, so other applies its own
+// options. If other is closed, they're dropped. A nil other disables the tee.
+func WithTee[T any](other *ringBuffer[T]) Option[T] {
+	return func(opts *options[T]) {
+		opts.tee = other
+	}
+}
+
+// WithDefaultCapacity sets the capacity New uses if the given one is less
+// than 1, e.g. when it's computed and may turn out to be zero. A valid
+// capacity passed to New takes precedence. If n is less than 1 as well, New
+// returns ErrInvalidBuffCap.
+func WithDefaultCapacity[T any](n int) Option[T] {
+	return func(opts *options[T]) {
+		opts.defaultCap = n
+	}
+}
+
+// isNil reports whether v is nil or holds a nil value of a nillable type,
+// e.g. a nil pointer, which a plain comparison with nil doesn't detect.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Func, reflect.Map, reflect.Chan, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,1099 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// countingObserver records the number of calls of each Observer method.
+type countingObserver struct {
+	pushes     int
+	pops       int
+	overwrites int
+	fulls      int
+}
+
+func (o *countingObserver) OnPush()      { o.pushes++ }
+func (o *countingObserver) OnPop()       { o.pops++ }
+func (o *countingObserver) OnOverwrite() { o.overwrites++ }
+func (o *countingObserver) OnFull()      { o.fulls++ }
+
+func TestWithObserver(t *testing.T) {
+	observer := &countingObserver{}
+	buffer, err := New[int](3, WithObserver[int](observer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer.Push(1)
+	buffer.Push(2)
+	buffer.Push(3) // fills the buffer
+	buffer.Push(4) // overwrites
+	buffer.Push(5) // overwrites
+	buffer.Pop()
+	buffer.Push(6) // fills the buffer again
+	if err := buffer.TryPush(7); err == nil {
+		t.Errorf("expected an error on TryPush to full buffer")
+	}
+	buffer.Pop()
+	buffer.Pop()
+	buffer.Pop()
+	buffer.Pop() // empty buffer, not counted
+	buffer.TryPop()
+
+	want := countingObserver{pushes: 6, pops: 4, overwrites: 2, fulls: 2}
+	if *observer != want {
+		t.Errorf("observer calls: want %+v, got %+v", want, *observer)
+	}
+}
+
+func TestWithObserverPushSlice(t *testing.T) {
+	observer := &countingObserver{}
+	buffer, err := New[int](3, WithObserver[int](observer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer.PushSlice([]int{1, 2})    // no overwrite
+	buffer.PushSlice([]int{3, 4, 5}) // fills the buffer and overwrites twice
+	buffer.PushSlice([]int{6})       // overwrites
+	buffer.PopAll()
+
+	want := countingObserver{pushes: 6, pops: 3, overwrites: 3, fulls: 1}
+	if *observer != want {
+		t.Errorf("observer calls: want %+v, got %+v", want, *observer)
+	}
+}
+
+func TestWithInitialData(t *testing.T) {
+	testCases := []struct {
+		name      string
+		bufCap    int
+		opts      []Option[int]
+		items     []int
+		wantItems []int
+	}{
+		{name: "no items", bufCap: 3, items: []int{}, wantItems: []int{}},
+		{name: "nil items", bufCap: 3, items: nil, wantItems: []int{}},
+		{name: "fewer than capacity", bufCap: 5, items: []int{1, 2, 3}, wantItems: []int{1, 2, 3}},
+		{name: "equal to capacity", bufCap: 3, items: []int{1, 2, 3}, wantItems: []int{1, 2, 3}},
+		{name: "more than capacity", bufCap: 3, items: []int{1, 2, 3, 4, 5, 6, 7}, wantItems: []int{5, 6, 7}},
+		{
+			name:      "no wrap",
+			bufCap:    2,
+			opts:      []Option[int]{WithNoWrap[int]()},
+			items:     []int{1, 2, 3},
+			wantItems: []int{1, 2},
+		},
+		{
+			name:      "auto grow",
+			bufCap:    2,
+			opts:      []Option[int]{WithAutoGrow[int](8)},
+			items:     []int{1, 2, 3},
+			wantItems: []int{1, 2, 3},
+		},
+		{
+			name:      "dedup",
+			bufCap:    3,
+			opts:      []Option[int]{WithDedup(func(a, b int) bool { return a == b })},
+			items:     []int{1, 1, 2},
+			wantItems: []int{1, 2},
+		},
+		{
+			name:   "validator",
+			bufCap: 3,
+			opts: []Option[int]{WithValidator(func(n int) error {
+				if n < 0 {
+					return fmt.Errorf("negative number")
+				}
+				return nil
+			})},
+			items:     []int{1, -2, 3},
+			wantItems: []int{1, 3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := New[int](tc.bufCap, append(tc.opts, WithInitialData(tc.items))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if buffer.Size() != len(tc.wantItems) {
+				t.Errorf("buffer size: want %d, got %d", len(tc.wantItems), buffer.Size())
+			}
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItems, got)
+			}
+
+			// The buffer must continue from the prefilled state.
+			buffer.Pop()
+			buffer.Push(100)
+			want := append(tc.wantItems[min(1, len(tc.wantItems)):], 100)
+			for _, item := range want {
+				if got, _ := buffer.Pop(); got != item {
+					t.Errorf("Pop() item: want %d, got %d", item, got)
+				}
+			}
+		})
+	}
+
+	t.Run("tee", func(t *testing.T) {
+		tee := newWrappedBuffer[int](t, 5, 0)
+		if _, err := New(2, WithTee(tee), WithInitialData([]int{1, 2, 3})); err != nil {
+			t.Fatal(err)
+		}
+		if want := []int{1, 2, 3}; !reflect.DeepEqual(tee.Snapshot(), want) {
+			t.Errorf("tee items: want %v, got %v", want, tee.Snapshot())
+		}
+	})
+}
+
+func TestWithClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+
+	t.Run("default clock", func(t *testing.T) {
+		buffer, err := New[int](1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buffer.now == nil || buffer.now().Before(start) {
+			t.Errorf("expected time.Now as the default clock")
+		}
+	})
+
+	t.Run("fake clock", func(t *testing.T) {
+		buffer, err := New(1, WithClock[int](clock.Now))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := buffer.now(); !got.Equal(start) {
+			t.Errorf("buffer time: want %v, got %v", start, got)
+		}
+		clock.Advance(time.Hour)
+		if got := buffer.now(); !got.Equal(start.Add(time.Hour)) {
+			t.Errorf("buffer time: want %v, got %v", start.Add(time.Hour), got)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		buffer, err := NewTTL(3, time.Minute, WithClock[int](clock.Now))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.Push(1)
+		if buffer.Size() != 1 {
+			t.Errorf("buffer size: want 1, got %d", buffer.Size())
+		}
+		// No real time passes, only the fake clock is advanced.
+		clock.Advance(2 * time.Minute)
+		if buffer.Size() != 0 {
+			t.Errorf("buffer size: want 0, got %d", buffer.Size())
+		}
+	})
+}
+
+func TestWithFillSampler(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		buffer, err := New[int](3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.Push(1)
+		if got := buffer.FillSamples(); got != nil {
+			t.Errorf("fill samples: want nil, got %v", got)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		buffer, err := New(3, WithFillSampler[int](5))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := buffer.FillSamples(); len(got) != 0 {
+			t.Errorf("fill samples: want none, got %v", got)
+		}
+
+		buffer.Push(1)
+		buffer.Push(2)
+		buffer.Pop()
+		want := []int{1, 2, 1}
+		if got := buffer.FillSamples(); !reflect.DeepEqual(got, want) {
+			t.Errorf("fill samples: want %v, got %v", want, got)
+		}
+
+		buffer.Push(3)
+		buffer.Push(4)
+		buffer.Push(5) // overwrites, the size stays the same
+		buffer.Pop()
+		buffer.Pop() // the oldest samples are dropped
+		want = []int{2, 3, 3, 2, 1}
+		if got := buffer.FillSamples(); !reflect.DeepEqual(got, want) {
+			t.Errorf("fill samples: want %v, got %v", want, got)
+		}
+
+		buffer.Pop()
+		buffer.Pop() // empty buffer, nothing is popped
+		buffer.PushFront(6)
+		want = []int{3, 2, 1, 0, 1}
+		if got := buffer.FillSamples(); !reflect.DeepEqual(got, want) {
+			t.Errorf("fill samples: want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestWithNoWrap(t *testing.T) {
+	observer := &countingObserver{}
+	buffer, err := New(3, WithNoWrap[int](), WithObserver[int](observer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		buffer.Push(i)
+		if want := min(i, 3); buffer.Size() != want {
+			t.Errorf("buffer size: want %d, got %d", want, buffer.Size())
+		}
+	}
+	buffer.PushFront(6)
+	if evicted := buffer.PushSlice([]int{7, 8}); evicted != nil {
+		t.Errorf("evicted items: want nil, got %v", evicted)
+	}
+
+	want := []int{1, 2, 3}
+	if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("buffer items: want %v, got %v", want, got)
+	}
+	wantCalls := countingObserver{pushes: 3, fulls: 1}
+	if *observer != wantCalls {
+		t.Errorf("observer calls: want %+v, got %+v", wantCalls, *observer)
+	}
+	if _, _, _, _, wrapped := buffer.Debug(); wrapped {
+		t.Errorf("full buffer: want wrapped false, got true")
+	}
+
+	// Freed space can be filled again.
+	buffer.Pop()
+	buffer.PushSlice([]int{9, 10})
+	want = []int{2, 3, 9}
+	if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("buffer items: want %v, got %v", want, got)
+	}
+	if _, _, _, _, wrapped := buffer.Debug(); wrapped {
+		t.Errorf("refilled buffer: want wrapped false, got true")
+	}
+}
+
+func TestWithAutoGrow(t *testing.T) {
+	observer := &countingObserver{}
+	buffer, err := New(2, WithAutoGrow[int](5), WithObserver[int](observer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		item    int
+		wantCap int
+	}{
+		{item: 1, wantCap: 2},
+		{item: 2, wantCap: 2},
+		{item: 3, wantCap: 4}, // doubles
+		{item: 4, wantCap: 4},
+		{item: 5, wantCap: 5}, // limited by maxCap
+		{item: 6, wantCap: 5}, // overwrites
+		{item: 7, wantCap: 5}, // overwrites
+	}
+	for _, step := range steps {
+		buffer.Push(step.item)
+		if buffer.Capacity() != step.wantCap {
+			t.Errorf("after Push(%d) capacity: want %d, got %d", step.item, step.wantCap, buffer.Capacity())
+		}
+	}
+
+	if buffer.Size() != 5 {
+		t.Errorf("buffer size: want 5, got %d", buffer.Size())
+	}
+	if observer.overwrites != 2 {
+		t.Errorf("overwrites: want 2, got %d", observer.overwrites)
+	}
+
+	t.Run("push slice", func(t *testing.T) {
+		buffer, err := New(2, WithAutoGrow[int](8))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if evicted := buffer.PushSlice([]int{1, 2, 3, 4, 5}); evicted != nil {
+			t.Errorf("evicted items: want nil, got %v", evicted)
+		}
+		if buffer.Capacity() != 8 {
+			t.Errorf("buffer capacity: want 8, got %d", buffer.Capacity())
+		}
+		want := []int{1, 2, 3, 4, 5}
+		if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("buffer items: want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestWithPow2Capacity(t *testing.T) {
+	testCases := []struct {
+		bufCap  int
+		wantCap int
+	}{
+		{bufCap: 1, wantCap: 1},
+		{bufCap: 2, wantCap: 2},
+		{bufCap: 3, wantCap: 4},
+		{bufCap: 5, wantCap: 8},
+		{bufCap: 8, wantCap: 8},
+		{bufCap: 1000, wantCap: 1024},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("cap: %d", tc.bufCap), func(t *testing.T) {
+			buffer, err := New(tc.bufCap, WithPow2Capacity[int]())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buffer.Capacity() != tc.wantCap {
+				t.Errorf("buffer capacity: want %d, got %d", tc.wantCap, buffer.Capacity())
+			}
+		})
+	}
+
+	t.Run("wrap around", func(t *testing.T) {
+		buffer, err := New(3, WithPow2Capacity[int]())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Keep the buffer half full while the indices wrap around a few times.
+		next := 0
+		for ; next < 2; next++ {
+			buffer.Push(next)
+		}
+		for want := 0; want < 20; want++ {
+			buffer.Push(next)
+			next++
+			if got, _ := buffer.Pop(); got != want {
+				t.Fatalf("Pop() item: want %d, got %d", want, got)
+			}
+		}
+		want := []int{20, 21}
+		if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("buffer items: want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("resize", func(t *testing.T) {
+		buffer, err := New(4, WithPow2Capacity[int]())
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.PushSlice([]int{1, 2, 3})
+		if err := buffer.Resize(5); err != nil {
+			t.Fatal(err)
+		}
+		if buffer.Capacity() != 8 {
+			t.Errorf("buffer capacity: want 8, got %d", buffer.Capacity())
+		}
+		buffer.Trim()
+		if buffer.Capacity() != 4 {
+			t.Errorf("buffer capacity: want 4, got %d", buffer.Capacity())
+		}
+		want := []int{1, 2, 3}
+		if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("buffer items: want %v, got %v", want, got)
+		}
+	})
+}
+
+// resizeObserver records the capacity changes reported by OnResize.
+type resizeObserver struct {
+	countingObserver
+	resizes [][2]int
+}
+
+func (o *resizeObserver) OnResize(oldCap, newCap int) {
+	o.resizes = append(o.resizes, [2]int{oldCap, newCap})
+}
+
+func TestResizeObserver(t *testing.T) {
+	observer := &resizeObserver{}
+	buffer, err := New(2, WithAutoGrow[int](4), WithObserver[int](observer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer.PushSlice([]int{1, 2, 3}) // grows automatically
+	buffer.Push(4)                   // fits without growing
+	buffer.Grow(4)
+	buffer.Discard(2)
+	buffer.Trim()
+	buffer.Trim() // already trimmed, not reported
+	if err := buffer.Resize(5); err != nil {
+		t.Fatal(err)
+	}
+	if err := buffer.Reset(3); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][2]int{{2, 4}, {4, 8}, {8, 2}, {2, 5}, {5, 3}}
+	if !reflect.DeepEqual(observer.resizes, want) {
+		t.Errorf("resizes: want %v, got %v", want, observer.resizes)
+	}
+}
+
+func TestWithDedup(t *testing.T) {
+	eq := func(a, b string) bool { return a == b }
+
+	t.Run("push", func(t *testing.T) {
+		buffer, err := New(5, WithDedup(eq))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range []string{"a", "a", "b", "b", "b", "a", "c", "c"} {
+			buffer.Push(item)
+		}
+		want := []string{"a", "b", "a", "c"}
+		if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("buffer items: want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("push slice", func(t *testing.T) {
+		buffer, err := New(5, WithDedup(eq))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.Push("a")
+		buffer.PushSlice([]string{"a", "b", "b", "a"})
+		want := []string{"a", "b", "a"}
+		if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("buffer items: want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("other push methods", func(t *testing.T) {
+		buffer, err := New(5, WithDedup(eq))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.Push("a")
+		if err := buffer.TryPush("a"); err != nil {
+			t.Fatal(err)
+		}
+		if err := buffer.TryPushBatch([]string{"a", "a", "b", "b"}); err != nil {
+			t.Fatal(err)
+		}
+		if n := buffer.Offer([]string{"b", "c"}); n != 2 {
+			t.Errorf("Offer(): want 2, got %d", n)
+		}
+		if item, ok := buffer.PushPop("c"); ok {
+			t.Errorf("PushPop(): want nothing popped, got %q", item)
+		}
+		want := []string{"a", "b", "c"}
+		if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("buffer items: want %v, got %v", want, got)
+		}
+
+		// Only the elements that aren't skipped need free space.
+		if err := buffer.TryPushBatch([]string{"c", "d", "d", "e"}); err != nil {
+			t.Errorf("TryPushBatch(): didn't expect an error: %v", err)
+		}
+	})
+
+	t.Run("after pop", func(t *testing.T) {
+		buffer, err := New(5, WithDedup(eq))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.Push("a")
+		buffer.Pop()
+		buffer.Push("a") // the buffer is empty, so there's nothing to compare with
+		want := []string{"a"}
+		if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("buffer items: want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestNilCallbacks(t *testing.T) {
+	var nilObserver *countingObserver
+
+	testCases := []struct {
+		name string
+		opts []Option[int]
+	}{
+		{name: "nil observer", opts: []Option[int]{WithObserver[int](nil)}},
+		{name: "nil pointer observer", opts: []Option[int]{WithObserver[int](nilObserver)}},
+		{name: "nil clock", opts: []Option[int]{WithClock[int](nil)}},
+		{name: "nil dedup", opts: []Option[int]{WithDedup[int](nil)}},
+		{name: "all nil", opts: []Option[int]{WithObserver[int](nilObserver), WithClock[int](nil), WithDedup[int](nil)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := New(2, append(tc.opts, WithAutoGrow[int](4))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buffer.Push(1)
+			buffer.PushFront(2)
+			buffer.PushSlice([]int{3, 4, 5})
+			buffer.Offer([]int{6})
+			buffer.TryPush(7)
+			buffer.TryPushBatch([]int{8})
+			buffer.Pop()
+			buffer.PopC()
+			buffer.PopNewest()
+			buffer.TryPop()
+			buffer.Discard(1)
+			buffer.PopAll()
+			buffer.Grow(2)
+			buffer.Trim()
+			if err := buffer.Resize(3); err != nil {
+				t.Fatal(err)
+			}
+			if err := buffer.Reset(2); err != nil {
+				t.Fatal(err)
+			}
+			buffer.Clear()
+			if buffer.now == nil {
+				t.Errorf("expected the default clock")
+			}
+		})
+	}
+
+	t.Run("ttl buffer", func(t *testing.T) {
+		buffer, err := NewTTL(2, time.Minute, WithObserver[int](nilObserver), WithClock[int](nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.Push(1)
+		buffer.Pop()
+		buffer.PurgeExpired()
+	})
+
+	t.Run("weighted buffer", func(t *testing.T) {
+		buffer, err := NewWeighted[int](2, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			if err := buffer.Push(i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if buffer.Weight() != 2 {
+			t.Errorf("buffer weight: want 2, got %d", buffer.Weight())
+		}
+		buffer.Pop()
+	})
+}
+
+func TestWithFlushOnFull(t *testing.T) {
+	var flushed [][]int
+	buffer, err := New(3, WithFlushOnFull(func(items []int) {
+		flushed = append(flushed, items)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer.Push(1)
+	buffer.Push(2)
+	if len(flushed) != 0 {
+		t.Fatalf("unexpected flush before the buffer is full: %v", flushed)
+	}
+	buffer.Push(3)
+	want := [][]int{{1, 2, 3}}
+	if !reflect.DeepEqual(flushed, want) {
+		t.Errorf("flushed batches: want %v, got %v", want, flushed)
+	}
+	if !buffer.IsEmpty() {
+		t.Errorf("empty buffer expected, got size: %d", buffer.Size())
+	}
+
+	buffer.PushFront(4)
+	buffer.PushSlice([]int{5, 6, 7, 8, 9, 10, 11})
+	want = [][]int{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}
+	if !reflect.DeepEqual(flushed, want) {
+		t.Errorf("flushed batches: want %v, got %v", want, flushed)
+	}
+	if got := buffer.Snapshot(); !reflect.DeepEqual(got, []int{10, 11}) {
+		t.Errorf("buffer items: want [10 11], got %v", got)
+	}
+
+	// The other push methods must flush the batches they fill as well.
+	testCases := []struct {
+		name      string
+		capacity  int
+		push      func(buffer *ringBuffer[int])
+		wantFlush [][]int
+		wantItems []int
+	}{
+		{
+			name:      "Offer",
+			capacity:  2,
+			push:      func(buffer *ringBuffer[int]) { buffer.Offer([]int{1, 2, 3}) },
+			wantFlush: [][]int{{1, 2}},
+			wantItems: []int{3},
+		},
+		{
+			name:      "TryPushBatch",
+			capacity:  2,
+			push:      func(buffer *ringBuffer[int]) { _ = buffer.TryPushBatch([]int{1, 2}) },
+			wantFlush: [][]int{{1, 2}},
+			wantItems: []int{},
+		},
+		{
+			name:      "PushPop",
+			capacity:  1,
+			push:      func(buffer *ringBuffer[int]) { buffer.PushPop(1) },
+			wantFlush: [][]int{{1}},
+			wantItems: []int{},
+		},
+		{
+			name:      "ReplaceAll",
+			capacity:  2,
+			push:      func(buffer *ringBuffer[int]) { buffer.ReplaceAll([]int{1, 2, 3, 4, 5}) },
+			wantFlush: [][]int{{1, 2}, {3, 4}},
+			wantItems: []int{5},
+		},
+		{
+			name:     "Move",
+			capacity: 2,
+			push: func(buffer *ringBuffer[int]) {
+				newWrappedBuffer(t, 5, 0, 1, 2, 3).Move(buffer, 3)
+			},
+			wantFlush: [][]int{{1, 2}},
+			wantItems: []int{3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var flushed [][]int
+			buffer, err := New(tc.capacity, WithFlushOnFull(func(items []int) {
+				flushed = append(flushed, items)
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.push(buffer)
+			if !reflect.DeepEqual(flushed, tc.wantFlush) {
+				t.Errorf("flushed batches: want %v, got %v", tc.wantFlush, flushed)
+			}
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItems, got)
+			}
+		})
+	}
+}
+
+func TestWithZeroOnPop(t *testing.T) {
+	testCases := []struct {
+		zero    bool
+		wantRaw []int
+	}{
+		{zero: true, wantRaw: []int{0, 6, 0, 0}},
+		{zero: false, wantRaw: []int{5, 6, 7, 4}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("zero: %t", tc.zero), func(t *testing.T) {
+			buffer, err := New(4, WithZeroOnPop[int](tc.zero))
+			if err != nil {
+				t.Fatal(err)
+			}
+			buffer.PushSlice([]int{1, 2, 3, 4, 5, 6, 7})
+			if item, _ := buffer.Pop(); item != 4 {
+				t.Errorf("Pop() item: want 4, got %d", item)
+			}
+			if item, _ := buffer.PopNewest(); item != 7 {
+				t.Errorf("PopNewest() item: want 7, got %d", item)
+			}
+			buffer.Discard(1)
+
+			// The stale elements must never be visible through the buffer.
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, []int{6}) {
+				t.Errorf("buffer items: want [6], got %v", got)
+			}
+			if got := buffer.RawData(); !reflect.DeepEqual(got, tc.wantRaw) {
+				t.Errorf("raw data: want %v, got %v", tc.wantRaw, got)
+			}
+			buffer.PushFront(8)
+			buffer.Push(9)
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, []int{8, 6, 9}) {
+				t.Errorf("buffer items: want [8 6 9], got %v", got)
+			}
+		})
+	}
+
+	t.Run("DeepClear drained buffer", func(t *testing.T) {
+		buffer, err := New(3, WithZeroOnPop[int](false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.PushSlice([]int{7, 8, 9})
+		buffer.Discard(3)
+
+		// The stale references of the empty buffer must be released as well.
+		buffer.DeepClear()
+		if got := buffer.RawData(); !reflect.DeepEqual(got, []int{0, 0, 0}) {
+			t.Errorf("raw data: want [0 0 0], got %v", got)
+		}
+	})
+}
+
+func TestWithValidator(t *testing.T) {
+	errNegative := fmt.Errorf("negative number")
+	newValidated := func(t *testing.T, capacity int) *ringBuffer[int] {
+		t.Helper()
+		buffer, err := New(capacity, WithValidator(func(n int) error {
+			if n < 0 {
+				return errNegative
+			}
+			return nil
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buffer
+	}
+
+	t.Run("batch methods", func(t *testing.T) {
+		buffer := newValidated(t, 10)
+		buffer.PushSlice([]int{1, -1, 2})
+		buffer.PushFront(-2)
+		if n := buffer.Offer([]int{-3, 3}); n != 2 {
+			t.Errorf("Offer(): want 2, got %d", n)
+		}
+		if err := buffer.TryPushBatch([]int{4, -4}); !errors.Is(err, errNegative) {
+			t.Errorf("want error: %v, got error: %v", errNegative, err)
+		}
+		if item, ok := buffer.PushPop(-5); ok {
+			t.Errorf("PushPop(): want 0, false, got %d, %t", item, ok)
+		}
+		if item, ok := buffer.SwapOldest(-5); ok {
+			t.Errorf("SwapOldest(): want 0, false, got %d, %t", item, ok)
+		}
+		want := []int{1, 2, 3}
+		if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("buffer items: want %v, got %v", want, got)
+		}
+
+		buffer.ReplaceAll([]int{-6, 5, -7})
+		want = []int{5}
+		if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("buffer items: want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("move", func(t *testing.T) {
+		src := newWrappedBuffer(t, 5, 0, 1, 2, -3, 4)
+		dst := newValidated(t, 5)
+		if moved := src.Move(dst, 4); moved != 2 {
+			t.Errorf("Move(): want 2, got %d", moved)
+		}
+		if want := []int{-3, 4}; !reflect.DeepEqual(src.Snapshot(), want) {
+			t.Errorf("src items: want %v, got %v", want, src.Snapshot())
+		}
+		if want := []int{1, 2}; !reflect.DeepEqual(dst.Snapshot(), want) {
+			t.Errorf("dst items: want %v, got %v", want, dst.Snapshot())
+		}
+	})
+
+	buffer := newValidated(t, 3)
+	if err := buffer.PushValidated(1); err != nil {
+		t.Errorf("didn't expect an error: %v", err)
+	}
+	if err := buffer.PushValidated(-1); !errors.Is(err, errNegative) {
+		t.Errorf("want error: %v, got error: %v", errNegative, err)
+	}
+	if err := buffer.TryPush(-2); !errors.Is(err, errNegative) {
+		t.Errorf("want error: %v, got error: %v", errNegative, err)
+	}
+	buffer.Push(-3)
+	buffer.Push(2)
+
+	want := []int{1, 2}
+	if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("buffer items: want %v, got %v", want, got)
+	}
+}
+
+func TestWithShrinkPolicy(t *testing.T) {
+	observer := &resizeObserver{}
+	buffer, err := New(1024, WithShrinkPolicy[int](0.25), WithObserver[int](observer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1024; i++ {
+		buffer.Push(i)
+	}
+
+	next := 0
+	for ; next < 1000; next++ {
+		if got, _ := buffer.Pop(); got != next {
+			t.Fatalf("Pop() item: want %d, got %d", next, got)
+		}
+	}
+	// The buffer is halved after each 16 pops below a quarter of its
+	// capacity, and the last 8 pops aren't enough for another shrink.
+	wantResizes := [][2]int{{1024, 512}, {512, 256}, {256, 128}}
+	if !reflect.DeepEqual(observer.resizes, wantResizes) {
+		t.Errorf("resizes: want %v, got %v", wantResizes, observer.resizes)
+	}
+
+	// The remaining elements must keep their order.
+	want := make([]int, 0, 24)
+	for ; next < 1024; next++ {
+		want = append(want, next)
+	}
+	if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("buffer items: want %v, got %v", want, got)
+	}
+
+	// A burst must grow the buffer back to its initial capacity instead of
+	// overwriting the elements.
+	buffer.Clear()
+	for i := 0; i < 1024; i++ {
+		buffer.Push(i)
+	}
+	if buffer.Capacity() != 1024 || buffer.Size() != 1024 {
+		t.Errorf("buffer capacity and size: want 1024, 1024, got %d, %d", buffer.Capacity(), buffer.Size())
+	}
+	if got := buffer.StatsSnapshot().Overwritten; got != 0 {
+		t.Errorf("overwritten elements: want 0, got %d", got)
+	}
+	buffer.Push(1024) // the initial capacity is the limit
+	if buffer.Capacity() != 1024 || buffer.Overflowed() != 1 {
+		t.Errorf("buffer capacity and overflows: want 1024, 1, got %d, %d", buffer.Capacity(), buffer.Overflowed())
+	}
+
+	t.Run("explicit resize", func(t *testing.T) {
+		buffer, err := New(8, WithShrinkPolicy[int](0.25))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := buffer.Resize(2); err != nil {
+			t.Fatal(err)
+		}
+		// Only the buffers shrunk by the policy grow back.
+		buffer.PushSlice([]int{1, 2, 3, 4, 5})
+		if buffer.Capacity() != 2 {
+			t.Errorf("buffer capacity: want 2, got %d", buffer.Capacity())
+		}
+		if want := []int{4, 5}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 64, 0, randomNumbers(64, 0, 10)...)
+		buffer.Discard(32)
+		for !buffer.IsEmpty() {
+			buffer.Pop()
+		}
+		if buffer.Capacity() != 64 {
+			t.Errorf("buffer capacity: want 64, got %d", buffer.Capacity())
+		}
+	})
+}
+
+func TestWithOverflow(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []Option[int]
+		wantCap  int
+		wantItem []int
+	}{
+		{name: "default", wantCap: 3, wantItem: []int{2, 3, 4}},
+		{name: "overwrite oldest", opts: []Option[int]{WithOverflow[int](OverwriteOldest)}, wantCap: 3, wantItem: []int{2, 3, 4}},
+		{name: "reject new", opts: []Option[int]{WithOverflow[int](RejectNew)}, wantCap: 3, wantItem: []int{1, 2, 3}},
+		{name: "grow", opts: []Option[int]{WithOverflow[int](Grow)}, wantCap: 6, wantItem: []int{1, 2, 3, 4}},
+		{name: "replaces no wrap", opts: []Option[int]{WithNoWrap[int](), WithOverflow[int](OverwriteOldest)}, wantCap: 3, wantItem: []int{2, 3, 4}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := New(3, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buffer.PushSlice([]int{1, 2, 3})
+			buffer.Push(4)
+			if buffer.Capacity() != tc.wantCap {
+				t.Errorf("buffer capacity: want %d, got %d", tc.wantCap, buffer.Capacity())
+			}
+			if got := buffer.Snapshot(); !reflect.DeepEqual(got, tc.wantItem) {
+				t.Errorf("buffer items: want %v, got %v", tc.wantItem, got)
+			}
+		})
+	}
+
+	t.Run("block", func(t *testing.T) {
+		buffer, err := New(3, WithOverflow[int](Block))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.PushSlice([]int{1, 2, 3})
+
+		done := make(chan struct{})
+		go func() {
+			buffer.Push(4)
+			buffer.PushFront(0)
+			close(done)
+		}()
+		select {
+		case <-done:
+			t.Fatal("Push returned while the buffer was full")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		buffer.Pop()
+		buffer.Pop()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Push was not woken up after Pop")
+		}
+		want := []int{0, 3, 4}
+		if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("buffer items: want %v, got %v", want, got)
+		}
+		if err := buffer.TryPush(5); !errors.Is(err, ErrBufferIsFull) {
+			t.Errorf("want error: %v, got error: %v", ErrBufferIsFull, err)
+		}
+	})
+}
+
+func TestWithTee(t *testing.T) {
+	t.Run("sequence", func(t *testing.T) {
+		tee := newWrappedBuffer[int](t, 10, 0)
+		buffer, err := New(3, WithTee(tee))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i <= 5; i++ {
+			buffer.Push(i)
+		}
+		if want := []int{3, 4, 5}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+		}
+		if want := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(tee.Snapshot(), want) {
+			t.Errorf("tee items: want %v, got %v", want, tee.Snapshot())
+		}
+	})
+
+	t.Run("tee policy", func(t *testing.T) {
+		tee, err := New(2, WithNoWrap[int]())
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer, err := New(3, WithTee(tee))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i <= 3; i++ {
+			buffer.Push(i)
+		}
+		if want := []int{1, 2}; !reflect.DeepEqual(tee.Snapshot(), want) {
+			t.Errorf("tee items: want %v, got %v", want, tee.Snapshot())
+		}
+	})
+
+	t.Run("all insertion paths", func(t *testing.T) {
+		tee := newWrappedBuffer[int](t, 20, 0)
+		buffer, err := New(10, WithTee(tee))
+		if err != nil {
+			t.Fatal(err)
+		}
+		src := newWrappedBuffer(t, 3, 0, 11, 12)
+
+		buffer.Push(1)
+		buffer.PushSlice([]int{2, 3})
+		buffer.PushFront(4)
+		buffer.Offer([]int{5, 6})
+		if err := buffer.TryPushBatch([]int{7, 8}); err != nil {
+			t.Fatal(err)
+		}
+		buffer.PushPop(9)
+		buffer.ReplaceAll([]int{10})
+		src.Move(buffer, 2)
+		want := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		if got := tee.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("tee items: want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("closed tee", func(t *testing.T) {
+		tee := newWrappedBuffer[int](t, 3, 0, 1)
+		tee.Close()
+		buffer, err := New(3, WithTee(tee))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buffer.Push(2)
+		buffer.PushSlice([]int{3, 4})
+		if want := []int{2, 3, 4}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+		}
+		if want := []int{1}; !reflect.DeepEqual(tee.Snapshot(), want) {
+			t.Errorf("tee items: want %v, got %v", want, tee.Snapshot())
+		}
+	})
+
+	t.Run("dropped elements", func(t *testing.T) {
+		tee := newWrappedBuffer[int](t, 10, 0)
+		buffer, err := New(2, WithNoWrap[int](), WithTee(tee))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i <= 3; i++ {
+			buffer.Push(i)
+		}
+		if err := buffer.TryPush(4); !errors.Is(err, ErrBufferIsFull) {
+			t.Errorf("want error: %v, got error: %v", ErrBufferIsFull, err)
+		}
+		if want := []int{1, 2}; !reflect.DeepEqual(tee.Snapshot(), want) {
+			t.Errorf("tee items: want %v, got %v", want, tee.Snapshot())
+		}
+	})
+}
+
+func TestWithDefaultCapacity(t *testing.T) {
+	testCases := []struct {
+		name       string
+		capacity   int
+		defaultCap int
+		wantCap    int
+		wantErr    error
+	}{
+		{name: "zero capacity", capacity: 0, defaultCap: 8, wantCap: 8},
+		{name: "negative capacity", capacity: -3, defaultCap: 8, wantCap: 8},
+		{name: "explicit capacity", capacity: 4, defaultCap: 8, wantCap: 4},
+		{name: "invalid default", capacity: 0, defaultCap: 0, wantErr: ErrInvalidBuffCap},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := New(tc.capacity, WithDefaultCapacity[int](tc.defaultCap))
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("want error: %v, got error: %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if buffer.Capacity() != tc.wantCap {
+				t.Errorf("buffer capacity: want %d, got %d", tc.wantCap, buffer.Capacity())
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package buffer
+
+import "sync"
+
+// bufferPool is a pool of ring buffers of a fixed capacity, built on
+// sync.Pool. It reduces allocations when short-lived buffers are needed, e.g.
+// one per request.
+type bufferPool[T any] struct {
+	pool     sync.Pool
+	capacity int
+}
+
+// Get returns an empty buffer with the pool capacity, either reused from the
+// pool or newly created.
+func (bp *bufferPool[T]) Get() *ringBuffer[T] {
+	return bp.pool.Get().(*ringBuffer[T])
+}
+
+// Put returns the buffer to the pool. The buffer is emptied, its cells are
+// zeroed so that the pool doesn't retain the elements, and its capacity is
+// restored if it was changed. The rest of its state is reset as well: a closed
+// buffer is reopened, its subscriptions are closed, and its counters, such as
+// the ones of StatsSnapshot or Overflowed, start from zero. The buffer must
+// not be used after Put.
+func (bp *bufferPool[T]) Put(rb *ringBuffer[T]) {
+	if rb == nil {
+		return
+	}
+	// Reset reuses the backing array if it's large enough, and zeroes it.
+	_ = rb.Reset(bp.capacity)
+	rb.recycle()
+	bp.pool.Put(rb)
+}
+
+// Capacity returns the capacity of the buffers in the pool.
+func (bp *bufferPool[T]) Capacity() int {
+	return bp.capacity
+}
+
+// NewPool returns a new pool of ring buffers with the given capacity,
+// configured by the given options.
+// If the specified capacity is less than 1, returns ErrInvalidBuffCap.
+func NewPool[T any](capacity int, opts ...Option[T]) (bp *bufferPool[T], err error) {
+	if capacity < 1 {
+		return bp, ErrInvalidBuffCap
+	}
+
+	bp = &bufferPool[T]{capacity: capacity}
+	bp.pool.New = func() any {
+		return MustNew(capacity, opts...)
+	}
+	return bp, nil
+}
+
+// recycle resets the state of the buffer that Reset keeps, so that it can be
+// handed out by the pool as a new one.
+func (rb *ringBuffer[T]) recycle() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	for s := range rb.subs {
+		s.closed = true
+	}
+	rb.subs = nil
+	rb.notEmptyCond.Broadcast()
+	rb.closed = false
+	rb.pushed, rb.popped, rb.overwritten, rb.overflowed = 0, 0, 0, 0
+	rb.seq, rb.pos = 0, 0
+	rb.lowLoadPops = 0
+	if rb.fillSamples != nil {
+		rb.fillSamples.Clear()
+	}
+	if rb.latency != nil {
+		rb.latency = &latencyRecorder{}
+	}
+}
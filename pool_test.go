@@ -0,0 +1,89 @@
+package buffer
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewPool(t *testing.T) {
+	if _, err := NewPool[int](0); !errors.Is(err, ErrInvalidBuffCap) {
+		t.Errorf("want error: %v, got error: %v", ErrInvalidBuffCap, err)
+	}
+
+	pool, err := NewPool[int](3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.Capacity() != 3 {
+		t.Errorf("pool capacity: want 3, got %d", pool.Capacity())
+	}
+	if buffer := pool.Get(); buffer.Capacity() != 3 || !buffer.IsEmpty() {
+		t.Errorf("want empty buffer with capacity 3, got size %d, capacity %d", buffer.Size(), buffer.Capacity())
+	}
+}
+
+func TestPoolPut(t *testing.T) {
+	pool, err := NewPool[int](3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := pool.Get()
+	buffer.PushSlice([]int{1, 2, 3})
+	if err := buffer.Resize(5); err != nil {
+		t.Fatal(err)
+	}
+	buffer.PushSlice([]int{4, 5})
+	backing := buffer.data[:cap(buffer.data)]
+	pool.Put(buffer)
+
+	// sync.Pool may drop the buffer, so check the put buffer itself.
+	if !buffer.IsEmpty() {
+		t.Errorf("empty buffer expected, got size: %d", buffer.Size())
+	}
+	if buffer.Capacity() != 3 || len(buffer.data) != 3 {
+		t.Errorf("buffer capacity: want 3, got %d", buffer.Capacity())
+	}
+	// The cells past the pool capacity must be zeroed as well.
+	for i, item := range backing {
+		if item != 0 {
+			t.Errorf("cell %d: want 0, got %d", i, item)
+		}
+	}
+
+	pool.Put(nil) // must be ignored
+	got := pool.Get()
+	if got.Capacity() != 3 || !got.IsEmpty() {
+		t.Errorf("want empty buffer with capacity 3, got size %d, capacity %d", got.Size(), got.Capacity())
+	}
+}
+
+func TestPoolPutResetsState(t *testing.T) {
+	pool, err := NewPool[int](3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := pool.Get()
+	sub := buffer.Subscribe()
+	buffer.PushSlice([]int{1, 2, 3, 4})
+	buffer.Close()
+	pool.Put(buffer)
+
+	// The reopened buffer must accept elements and start from fresh counters.
+	buffer.Push(5)
+	want := Stats{Size: 1, Capacity: 3, Pushed: 1}
+	if got := buffer.StatsSnapshot(); got != want {
+		t.Errorf("stats: want %+v, got %+v", want, got)
+	}
+	if buffer.Overflowed() != 0 {
+		t.Errorf("overflowed elements: want 0, got %d", buffer.Overflowed())
+	}
+	if buffer.HeadSeq() != 1 {
+		t.Errorf("head sequence number: want 1, got %d", buffer.HeadSeq())
+	}
+	if _, ok := sub.Next(context.Background()); ok {
+		t.Errorf("Next() on a subscription of a recycled buffer must return false")
+	}
+}
@@ -0,0 +1,46 @@
+package buffer
+
+import (
+	"errors"
+	"io"
+)
+
+// ByteRing is a ring buffer of bytes, e.g. holding the tail of a stream.
+type ByteRing = ringBuffer[byte]
+
+// readChunkSize is the maximum number of bytes NewFromReader reads at once.
+const readChunkSize = 32 * 1024
+
+// NewFromReader returns a new byte ring buffer with the given capacity,
+// configured by the given options and filled with the bytes read from r until
+// io.EOF. Like Push, it keeps the last capacity bytes of the stream by
+// default. With WithNoWrap, it stops reading once the buffer is full, keeping
+// the first capacity bytes and leaving the rest of the stream unread. The same
+// applies to WithOverflow(Block), since there is no consumer to wait for yet.
+// If the specified capacity is less than 1, returns ErrInvalidBuffCap, unless
+// WithDefaultCapacity provides one. If reading fails, returns the read error.
+func NewFromReader(capacity int, r io.Reader, opts ...Option[byte]) (rb *ByteRing, err error) {
+	rb, err = New(capacity, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := make([]byte, min(rb.Capacity(), readChunkSize))
+	for {
+		n := len(chunk)
+		if rb.noWrap || rb.block {
+			n = min(n, rb.Capacity()-rb.Size())
+			if n == 0 {
+				return rb, nil
+			}
+		}
+		n, err = r.Read(chunk[:n])
+		rb.PushSlice(chunk[:n])
+		if errors.Is(err, io.EOF) {
+			return rb, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package buffer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNewFromReader(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog")
+
+	testCases := []struct {
+		name     string
+		capacity int
+		opts     []Option[byte]
+		want     string
+		wantRest string
+	}{
+		{name: "tail", capacity: 8, want: "lazy dog"},
+		{name: "whole stream", capacity: 64, want: string(data)},
+		{name: "no wrap", capacity: 9, opts: []Option[byte]{WithNoWrap[byte]()}, want: "the quick", wantRest: " brown fox jumps over the lazy dog"},
+		{name: "default capacity", capacity: 0, opts: []Option[byte]{WithDefaultCapacity[byte](8)}, want: "lazy dog"},
+		{name: "block", capacity: 9, opts: []Option[byte]{WithOverflow[byte](Block)}, want: "the quick", wantRest: " brown fox jumps over the lazy dog"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := bytes.NewReader(data)
+			buffer, err := NewFromReader(tc.capacity, r, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(buffer.Snapshot()); got != tc.want {
+				t.Errorf("buffer items: want %q, got %q", tc.want, got)
+			}
+			rest, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(rest) != tc.wantRest {
+				t.Errorf("unread bytes: want %q, got %q", tc.wantRest, rest)
+			}
+		})
+	}
+
+	t.Run("small reads", func(t *testing.T) {
+		buffer, err := NewFromReader(8, iotest.OneByteReader(bytes.NewReader(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buffer.Snapshot()); got != "lazy dog" {
+			t.Errorf("buffer items: want %q, got %q", "lazy dog", got)
+		}
+	})
+
+	t.Run("invalid capacity", func(t *testing.T) {
+		if _, err := NewFromReader(0, bytes.NewReader(data)); !errors.Is(err, ErrInvalidBuffCap) {
+			t.Errorf("want error: %v, got error: %v", ErrInvalidBuffCap, err)
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		readErr := errors.New("read failed")
+		if _, err := NewFromReader(8, iotest.ErrReader(readErr)); !errors.Is(err, readErr) {
+			t.Errorf("want error: %v, got error: %v", readErr, err)
+		}
+	})
+}
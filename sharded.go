@@ -0,0 +1,119 @@
+package buffer
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+var ErrInvalidShardCount = fmt.Errorf("shard count is less than 1 or exceeds capacity")
+
+// shardedBuffer is a thread-safe buffer that splits its capacity across
+// several independent ring buffers (shards) to reduce lock contention when
+// there are many concurrent producers and consumers.
+//
+// Push and Pop select shards in round-robin order, so the elements are FIFO
+// within a single shard, but the order across shards is not strictly FIFO.
+type shardedBuffer[T any] struct {
+	shards  []*ringBuffer[T]
+	cap     int
+	pushIdx atomic.Uint64
+	popIdx  atomic.Uint64
+}
+
+// Push adds an element to the next shard in round-robin order. If the shard
+// is full, overwrites its oldest element.
+func (sb *shardedBuffer[T]) Push(item T) {
+	idx := (sb.pushIdx.Add(1) - 1) % uint64(len(sb.shards))
+	sb.shards[idx].Push(item)
+}
+
+// Pop removes and returns an element from the next non-empty shard in
+// round-robin order. If all shards are empty, returns an empty value and false.
+func (sb *shardedBuffer[T]) Pop() (T, bool) {
+	n := uint64(len(sb.shards))
+	start := sb.popIdx.Add(1) - 1
+	for i := uint64(0); i < n; i++ {
+		if item, ok := sb.shards[(start+i)%n].Pop(); ok {
+			return item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// IsEmpty checks if all shards are empty.
+func (sb *shardedBuffer[T]) IsEmpty() bool {
+	for _, shard := range sb.shards {
+		if !shard.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsFull checks if all shards are full.
+func (sb *shardedBuffer[T]) IsFull() bool {
+	for _, shard := range sb.shards {
+		if !shard.IsFull() {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the total number of elements in all shards. Since the shards
+// are locked independently, the result is approximate under concurrent use.
+func (sb *shardedBuffer[T]) Size() int {
+	size := 0
+	for _, shard := range sb.shards {
+		size += shard.Size()
+	}
+	return size
+}
+
+// Capacity returns the total capacity of all shards.
+func (sb *shardedBuffer[T]) Capacity() int {
+	return sb.cap
+}
+
+// ShardStats returns the statistics of each shard, as returned by
+// StatsSnapshot, in the shard order. It helps to detect an imbalance between
+// the shards, e.g. to tune the shard count. Each shard is read under its own
+// lock, so the shards may be in different states under concurrent use.
+func (sb *shardedBuffer[T]) ShardStats() []Stats {
+	stats := make([]Stats, len(sb.shards))
+	for i, shard := range sb.shards {
+		stats[i] = shard.StatsSnapshot()
+	}
+	return stats
+}
+
+// NewSharded returns a new thread-safe buffer with the given total capacity
+// partitioned across the given number of shards. If the capacity is less than
+// 1, returns ErrInvalidBuffCap. If the shard count is less than 1 or greater
+// than the capacity, returns ErrInvalidShardCount.
+func NewSharded[T any](capacity, shards int) (sb *shardedBuffer[T], err error) {
+	if capacity < 1 {
+		return sb, ErrInvalidBuffCap
+	}
+	if shards < 1 || shards > capacity {
+		return sb, ErrInvalidShardCount
+	}
+
+	sb = &shardedBuffer[T]{
+		shards: make([]*ringBuffer[T], shards),
+		cap:    capacity,
+	}
+	for i := range sb.shards {
+		// Spread the remainder across the first shards.
+		shardCap := capacity / shards
+		if i < capacity%shards {
+			shardCap++
+		}
+		if sb.shards[i], err = New[T](shardCap); err != nil {
+			return nil, err
+		}
+	}
+
+	return sb, nil
+}
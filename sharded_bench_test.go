@@ -0,0 +1,68 @@
+package buffer
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// BenchmarkShardedBufferPushConcurrent is meant to be compared with
+// BenchmarkRingBufferPushConcurrent, which uses a single lock.
+func BenchmarkShardedBufferPushConcurrent(b *testing.B) {
+	bufCapacity := 2048
+	gorAmount := 100
+
+	for _, shards := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("shards: %d", shards), func(b *testing.B) {
+			buffer, err := NewSharded[int](bufCapacity, shards)
+			if err != nil {
+				b.Error(err)
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(gorAmount)
+
+			b.ResetTimer()
+			for i := 0; i < gorAmount; i++ {
+				go func() {
+					defer wg.Done()
+					for j := 0; j < b.N; j++ {
+						buffer.Push(j)
+					}
+				}()
+			}
+
+			wg.Wait()
+		})
+	}
+}
+
+func BenchmarkShardedBufferPushPopConcurrent(b *testing.B) {
+	bufCapacity := 2048
+	gorAmount := 100
+
+	for _, shards := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("shards: %d", shards), func(b *testing.B) {
+			buffer, err := NewSharded[int](bufCapacity, shards)
+			if err != nil {
+				b.Error(err)
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(gorAmount)
+
+			b.ResetTimer()
+			for i := 0; i < gorAmount; i++ {
+				go func() {
+					defer wg.Done()
+					for j := 0; j < b.N; j++ {
+						buffer.Push(j)
+						buffer.Pop()
+					}
+				}()
+			}
+
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestShardedBufferNew(t *testing.T) {
+	testCases := []struct {
+		capacity int
+		shards   int
+		wantErr  error
+		wantCaps []int
+	}{
+		{capacity: 0, shards: 1, wantErr: ErrInvalidBuffCap},
+		{capacity: 4, shards: 0, wantErr: ErrInvalidShardCount},
+		{capacity: 2, shards: 3, wantErr: ErrInvalidShardCount},
+		{capacity: 8, shards: 4, wantCaps: []int{2, 2, 2, 2}},
+		{capacity: 10, shards: 4, wantCaps: []int{3, 3, 2, 2}},
+		{capacity: 5, shards: 1, wantCaps: []int{5}},
+	}
+
+	for _, tc := range testCases {
+		name := fmt.Sprintf("cap: %d, shards: %d", tc.capacity, tc.shards)
+		t.Run(name, func(t *testing.T) {
+			buffer, err := NewSharded[int](tc.capacity, tc.shards)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("want error: %v, got error: %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if buffer.Capacity() != tc.capacity {
+				t.Errorf("buffer capacity: want %d, got %d", tc.capacity, buffer.Capacity())
+			}
+			for i, shard := range buffer.shards {
+				if shard.Capacity() != tc.wantCaps[i] {
+					t.Errorf("shard %d capacity: want %d, got %d", i, tc.wantCaps[i], shard.Capacity())
+				}
+			}
+		})
+	}
+}
+
+func TestShardedBufferPushPop(t *testing.T) {
+	buffer, err := NewSharded[int](6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !buffer.IsEmpty() {
+		t.Errorf("empty buffer expected")
+	}
+
+	for i := 0; i < 6; i++ {
+		buffer.Push(i)
+	}
+	if !buffer.IsFull() {
+		t.Errorf("expected full buffer")
+	}
+	if buffer.Size() != 6 {
+		t.Errorf("buffer size: want 6, got %d", buffer.Size())
+	}
+
+	// Round-robin routing keeps the order within each shard.
+	for i, shard := range buffer.shards {
+		want := []int{i, i + 3}
+		if got := shard.PeekOldestN(2); !reflect.DeepEqual(got, want) {
+			t.Errorf("shard %d items: want %v, got %v", i, want, got)
+		}
+	}
+
+	var got []int
+	for {
+		item, ok := buffer.Pop()
+		if !ok {
+			break
+		}
+		got = append(got, item)
+	}
+	want := []int{0, 1, 2, 3, 4, 5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("popped items: want %v, got %v", want, got)
+	}
+	if !buffer.IsEmpty() {
+		t.Errorf("empty buffer expected, got size: %d", buffer.Size())
+	}
+	if _, ok := buffer.Pop(); ok {
+		t.Errorf("expected ok: false on empty buffer")
+	}
+}
+
+func TestShardedBufferShardStats(t *testing.T) {
+	buffer, err := NewSharded[int](6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first two shards get an extra element each, which overwrites their
+	// oldest one, and the first shard is popped twice.
+	for i := 0; i < 8; i++ {
+		buffer.Push(i)
+	}
+	for i := 0; i < 4; i++ {
+		buffer.Pop()
+	}
+
+	want := []Stats{
+		{Size: 0, Capacity: 2, Pushed: 3, Popped: 2, Overwritten: 1},
+		{Size: 1, Capacity: 2, Pushed: 3, Popped: 1, Overwritten: 1},
+		{Size: 1, Capacity: 2, Pushed: 2, Popped: 1, Overwritten: 0},
+	}
+	if got := buffer.ShardStats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("shard stats: want %+v, got %+v", want, got)
+	}
+}
+
+func TestShardedBufferConcurrent(t *testing.T) {
+	gorAmount := 50
+	itemCount := 10_000
+	buffer, err := NewSharded[int](itemCount, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	itemChan := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range itemChan {
+				buffer.Push(item)
+			}
+		}()
+	}
+	for i := 0; i < itemCount; i++ {
+		itemChan <- i
+	}
+	close(itemChan)
+	wg.Wait()
+
+	if buffer.Size() != itemCount {
+		t.Fatalf("buffer size: want %d, got %d", itemCount, buffer.Size())
+	}
+
+	var mu sync.Mutex
+	got := make([]int, 0, itemCount)
+	for i := 0; i < gorAmount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item, ok := buffer.Pop()
+				if !ok {
+					return
+				}
+				mu.Lock()
+				got = append(got, item)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Ints(got)
+	for i, item := range got {
+		if item != i {
+			t.Fatalf("items in buffer do not match test data")
+		}
+	}
+	if len(got) != itemCount {
+		t.Errorf("popped items: want %d, got %d", itemCount, len(got))
+	}
+}
@@ -0,0 +1,184 @@
+package buffer
+
+import "context"
+
+// Subscription is an independent reader of a ring buffer created by
+// Subscribe. Every subscription receives all elements pushed to the buffer,
+// unlike Pop, which hands each element to a single consumer.
+type Subscription[T any] struct {
+	rb *ringBuffer[T]
+	// next is the position of the next element to read, see ringBuffer.pos.
+	// It's guarded by rb.mu.
+	next   uint64
+	closed bool
+}
+
+// OverrunObserver is an optional extension of Observer for buffers with
+// subscriptions. If the observer set by WithObserver also implements it, it's
+// notified when a subscription has lost elements because they were
+// overwritten or otherwise removed before it read them.
+type OverrunObserver[T any] interface {
+	OnOverrun(sub *Subscription[T], lost int)
+}
+
+// overrun is a loss of a subscription waiting to be reported to the
+// OverrunObserver.
+type overrun[T any] struct {
+	sub  *Subscription[T]
+	lost int
+}
+
+// Subscribe returns a new subscription, which reads the elements from the
+// oldest one in the buffer at the moment. The elements are kept in the buffer
+// until all subscriptions have read them, after which they're removed as if
+// by Pop. A subscription that reads slower than the buffer overwrites or
+// removes the elements loses them. The subscription must be closed when it's
+// no longer needed, otherwise it keeps the elements in the buffer.
+func (rb *ringBuffer[T]) Subscribe() *Subscription[T] {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	s := &Subscription[T]{rb: rb, next: rb.headPos()}
+	if rb.subs == nil {
+		rb.subs = make(map[*Subscription[T]]struct{})
+	}
+	rb.subs[s] = struct{}{}
+	return s
+}
+
+// Next returns the next element of the subscription, waiting until one is
+// pushed if the subscription has read all elements. If ctx is done before an
+// element is available, the subscription is closed, or the buffer is closed
+// and the subscription has read all elements, returns an empty value and
+// false.
+func (s *Subscription[T]) Next(ctx context.Context) (T, bool) {
+	rb := s.rb
+	rb.mu.Lock()
+	lost := 0
+	ready := rb.waitPush(ctx, func() bool {
+		if s.closed {
+			return true
+		}
+		n, ok := s.skipLost()
+		lost += n
+		return ok || rb.closed
+	})
+	// The subscription reads the remaining elements of a closed buffer.
+	if !ready || s.closed || s.next > rb.pos {
+		rb.mu.Unlock()
+		s.notifyOverrun(lost)
+		var zero T
+		return zero, false
+	}
+	item := rb.data[rb.physIdx(int(s.next-rb.headPos()))]
+	s.next++
+	released := rb.releaseRead()
+	rb.mu.Unlock()
+
+	s.notifyOverrun(lost)
+	rb.notifyPops(released)
+	return item, true
+}
+
+// Lag returns the number of elements pushed to the buffer that the
+// subscription hasn't read yet. The lost elements aren't counted once their
+// loss is detected, see OverrunObserver.
+func (s *Subscription[T]) Lag() int {
+	s.rb.mu.RLock()
+	defer s.rb.mu.RUnlock()
+	return int(s.rb.pos + 1 - s.next)
+}
+
+// Close closes the subscription, which lets the buffer remove the elements
+// the subscription hasn't read yet. A Next waiting for an element returns
+// false.
+func (s *Subscription[T]) Close() {
+	rb := s.rb
+	rb.mu.Lock()
+	if s.closed {
+		rb.mu.Unlock()
+		return
+	}
+	s.closed = true
+	delete(rb.subs, s)
+	rb.notEmptyCond.Broadcast()
+	released := rb.releaseRead()
+	rb.mu.Unlock()
+
+	rb.notifyPops(released)
+}
+
+// skipLost moves the subscription past the elements that were removed from
+// the buffer before it read them. Returns the number of skipped elements and
+// whether there's an element to read. The caller must hold the lock.
+func (s *Subscription[T]) skipLost() (lost int, ok bool) {
+	if head := s.rb.headPos(); s.next < head {
+		lost = int(head - s.next)
+		s.next = head
+	}
+	return lost, s.next <= s.rb.pos
+}
+
+// notifyOverrun notifies the observer, if it implements OverrunObserver, that
+// the subscription lost elements. Does nothing if lost is 0.
+func (s *Subscription[T]) notifyOverrun(lost int) {
+	if lost == 0 {
+		return
+	}
+	if o, ok := s.rb.observer.(OverrunObserver[T]); ok {
+		o.OnOverrun(s, lost)
+	}
+}
+
+// detectOverruns moves the subscriptions past the elements that were removed
+// before they read them, and records the losses to be reported by
+// notifyOverruns if the observer implements OverrunObserver. The caller must
+// hold the lock.
+func (rb *ringBuffer[T]) detectOverruns() {
+	if len(rb.subs) == 0 {
+		return
+	}
+	if _, ok := rb.observer.(OverrunObserver[T]); !ok {
+		return
+	}
+	for s := range rb.subs {
+		if lost, _ := s.skipLost(); lost > 0 {
+			rb.overruns = append(rb.overruns, overrun[T]{sub: s, lost: lost})
+		}
+	}
+	rb.hasOverruns.Store(len(rb.overruns) > 0)
+}
+
+// notifyOverruns reports the losses recorded by detectOverruns to the
+// observer. It must be called after the lock is released.
+func (rb *ringBuffer[T]) notifyOverruns() {
+	if !rb.hasOverruns.Load() {
+		return
+	}
+	rb.mu.Lock()
+	overruns := rb.overruns
+	rb.overruns = nil
+	rb.hasOverruns.Store(false)
+	rb.mu.Unlock()
+
+	for _, o := range overruns {
+		o.sub.notifyOverrun(o.lost)
+	}
+}
+
+// releaseRead removes the oldest elements that all subscriptions have read.
+// Returns the number of removed elements. The caller must hold the lock.
+func (rb *ringBuffer[T]) releaseRead() int {
+	if len(rb.subs) == 0 {
+		return 0
+	}
+	minNext := rb.pos + 1
+	for s := range rb.subs {
+		minNext = min(minNext, s.next)
+	}
+	n := 0
+	for rb.size.Load() > 0 && rb.headPos() < minNext {
+		rb.pop()
+		n++
+	}
+	return n
+}
@@ -0,0 +1,199 @@
+package buffer
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscription(t *testing.T) {
+	t.Run("fan-out", func(t *testing.T) {
+		itemCount := 20
+		buffer := newWrappedBuffer[int](t, 4, 0)
+		subs := []*Subscription[int]{buffer.Subscribe(), buffer.Subscribe()}
+
+		var wg sync.WaitGroup
+		got := make([][]int, len(subs))
+		for i, sub := range subs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range itemCount {
+					item, ok := sub.Next(context.Background())
+					if !ok {
+						return
+					}
+					got[i] = append(got[i], item)
+				}
+			}()
+		}
+		// The buffer is smaller than the sequence, so the producer must wait
+		// for the subscribers to avoid overwrites.
+		want := make([]int, itemCount)
+		for i := range want {
+			want[i] = i + 1
+			if err := buffer.PushTimeout(i+1, time.Second); err != nil {
+				t.Fatal(err)
+			}
+		}
+		wg.Wait()
+
+		for i := range subs {
+			if !reflect.DeepEqual(got[i], want) {
+				t.Errorf("subscription %d items: want %v, got %v", i, want, got[i])
+			}
+		}
+		// The elements read by all subscriptions must be removed.
+		if !buffer.IsEmpty() {
+			t.Errorf("empty buffer expected, got %v", buffer.Snapshot())
+		}
+	})
+
+	t.Run("existing elements", func(t *testing.T) {
+		buffer := newWrappedBuffer(t, 4, 0, 1, 2)
+		fast, slow := buffer.Subscribe(), buffer.Subscribe()
+		fast.Next(context.Background())
+		fast.Next(context.Background())
+		if want := []int{1, 2}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+		}
+		if item, _ := slow.Next(context.Background()); item != 1 {
+			t.Errorf("Next() item: want 1, got %d", item)
+		}
+		if want := []int{2}; !reflect.DeepEqual(buffer.Snapshot(), want) {
+			t.Errorf("buffer items: want %v, got %v", want, buffer.Snapshot())
+		}
+		// The closed subscription must not hold the elements anymore.
+		slow.Close()
+		if !buffer.IsEmpty() {
+			t.Errorf("empty buffer expected, got %v", buffer.Snapshot())
+		}
+	})
+
+	t.Run("overwritten elements", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		sub := buffer.Subscribe()
+		buffer.PushSlice([]int{1, 2, 3, 4, 5})
+		var got []int
+		for range 3 {
+			item, _ := sub.Next(context.Background())
+			got = append(got, item)
+		}
+		if want := []int{3, 4, 5}; !reflect.DeepEqual(got, want) {
+			t.Errorf("subscription items: want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("popped newest", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		sub, other := buffer.Subscribe(), buffer.Subscribe()
+		defer other.Close()
+		buffer.Push(1)
+		sub.Next(context.Background())
+		buffer.PopNewest()
+		buffer.Push(2)
+		if item, _ := sub.Next(context.Background()); item != 2 {
+			t.Errorf("Next() item: want 2, got %d", item)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		sub := buffer.Subscribe()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if item, ok := sub.Next(ctx); ok {
+			t.Errorf("Next(): want 0, false, got %d, %t", item, ok)
+		}
+	})
+
+	t.Run("closed buffer", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		sub := buffer.Subscribe()
+		buffer.Push(1)
+		buffer.Close()
+		if item, ok := sub.Next(context.Background()); !ok || item != 1 {
+			t.Errorf("Next(): want 1, true, got %d, %t", item, ok)
+		}
+		if item, ok := sub.Next(context.Background()); ok {
+			t.Errorf("Next(): want 0, false, got %d, %t", item, ok)
+		}
+	})
+
+	t.Run("closed while waiting", func(t *testing.T) {
+		buffer := newWrappedBuffer[int](t, 3, 0)
+		sub := buffer.Subscribe()
+		done := make(chan bool)
+		go func() {
+			_, ok := sub.Next(context.Background())
+			done <- ok
+		}()
+		time.Sleep(10 * time.Millisecond)
+		sub.Close()
+		select {
+		case ok := <-done:
+			if ok {
+				t.Errorf("Next() on a closed subscription must return false")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Next was not woken up after the subscription was closed")
+		}
+	})
+}
+
+// overrunObserver records the losses reported by OnOverrun.
+type overrunObserver struct {
+	countingObserver
+	lost map[*Subscription[int]]int
+}
+
+func (o *overrunObserver) OnOverrun(sub *Subscription[int], lost int) {
+	o.lost[sub] += lost
+}
+
+func TestSubscriptionOverrun(t *testing.T) {
+	observer := &overrunObserver{lost: make(map[*Subscription[int]]int)}
+	buffer, err := New(3, WithObserver[int](observer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, slow := buffer.Subscribe(), buffer.Subscribe()
+
+	for i := 1; i <= 5; i++ {
+		buffer.Push(i)
+		fast.Next(context.Background())
+	}
+	if fast.Lag() != 0 {
+		t.Errorf("fast subscription lag: want 0, got %d", fast.Lag())
+	}
+	// The stalled subscription lost 1 and 2, which were overwritten, and it
+	// must be reported without calling Next.
+	want := map[*Subscription[int]]int{slow: 2}
+	if !reflect.DeepEqual(observer.lost, want) {
+		t.Errorf("lost elements: want %v, got %v", want, observer.lost)
+	}
+	if slow.Lag() != 3 {
+		t.Errorf("slow subscription lag: want 3, got %d", slow.Lag())
+	}
+
+	if item, _ := slow.Next(context.Background()); item != 3 {
+		t.Errorf("Next() item: want 3, got %d", item)
+	}
+	if slow.Lag() != 2 {
+		t.Errorf("slow subscription lag: want 2, got %d", slow.Lag())
+	}
+	// The loss must be reported once.
+	if !reflect.DeepEqual(observer.lost, want) {
+		t.Errorf("lost elements: want %v, got %v", want, observer.lost)
+	}
+
+	// The elements removed by Clear are lost as well.
+	buffer.Push(6)
+	buffer.Clear()
+	want = map[*Subscription[int]]int{slow: 5, fast: 1}
+	if !reflect.DeepEqual(observer.lost, want) {
+		t.Errorf("lost elements: want %v, got %v", want, observer.lost)
+	}
+}
@@ -0,0 +1,168 @@
+package buffer
+
+import (
+	"fmt"
+	"time"
+)
+
+var ErrInvalidTTL = fmt.Errorf("ttl is not positive")
+
+// timedItem is a buffer element along with the time it was pushed at.
+type timedItem[T any] struct {
+	item     T
+	pushedAt time.Time
+}
+
+// ttlBuffer is a thread-safe ring buffer whose elements expire after a fixed
+// time to live. Expired elements are discarded lazily, when the buffer is
+// accessed, or explicitly with PurgeExpired.
+type ttlBuffer[T any] struct {
+	buf *ringBuffer[timedItem[T]]
+	ttl time.Duration
+}
+
+// Push adds an element to the buffer, stamped with the current time. If the
+// buffer is full, overwrites the oldest element.
+func (tb *ttlBuffer[T]) Push(item T) {
+	tb.buf.mu.Lock()
+	overwritten := tb.buf.push(timedItem[T]{item: item, pushedAt: tb.buf.now()})
+	filled := !overwritten && tb.buf.isFull()
+	tb.buf.mu.Unlock()
+
+	tb.buf.notifyPush(overwritten, filled)
+}
+
+// Pop discards the expired elements, then removes and returns the oldest
+// remaining element. If there is no such element, returns an empty value and
+// false.
+func (tb *ttlBuffer[T]) Pop() (T, bool) {
+	tb.buf.mu.Lock()
+	purged := tb.purgeExpired()
+	entry, ok := tb.buf.pop()
+	tb.buf.mu.Unlock()
+
+	if ok {
+		purged++
+	}
+	tb.buf.notifyPops(purged)
+	return entry.item, ok
+}
+
+// Get discards the expired elements, then returns the oldest remaining
+// element without removing it. If there is no such element, returns an empty
+// value and false.
+func (tb *ttlBuffer[T]) Get() (T, bool) {
+	item, _, ok := tb.GetWithTime()
+	return item, ok
+}
+
+// GetWithTime discards the expired elements, then returns the oldest
+// remaining element without removing it, along with the time it was pushed at.
+// If there is no such element, returns an empty value, zero time and false.
+func (tb *ttlBuffer[T]) GetWithTime() (T, time.Time, bool) {
+	tb.buf.mu.Lock()
+	purged := tb.purgeExpired()
+	entry, ok := tb.buf.data[tb.buf.readerIdx], tb.buf.size.Load() > 0
+	tb.buf.mu.Unlock()
+
+	tb.buf.notifyPops(purged)
+	if !ok {
+		var zero T
+		return zero, time.Time{}, false
+	}
+	return entry.item, entry.pushedAt, true
+}
+
+// GetLastWithAge discards the expired elements, then returns the newest
+// remaining element along with the time elapsed since it was pushed, which
+// tells how fresh the element is. If there is no such element, returns an
+// empty value, zero and false.
+func (tb *ttlBuffer[T]) GetLastWithAge() (T, time.Duration, bool) {
+	tb.buf.mu.Lock()
+	purged := tb.purgeExpired()
+	entry, ok := tb.buf.data[tb.buf.lastWriterIdx], tb.buf.size.Load() > 0
+	age := tb.buf.now().Sub(entry.pushedAt)
+	tb.buf.mu.Unlock()
+
+	tb.buf.notifyPops(purged)
+	if !ok {
+		var zero T
+		return zero, 0, false
+	}
+	return entry.item, age, true
+}
+
+// Size discards the expired elements and returns the number of remaining
+// elements.
+func (tb *ttlBuffer[T]) Size() int {
+	tb.buf.mu.Lock()
+	purged := tb.purgeExpired()
+	size := int(tb.buf.size.Load())
+	tb.buf.mu.Unlock()
+
+	tb.buf.notifyPops(purged)
+	return size
+}
+
+// IsEmpty checks if the buffer has no unexpired elements.
+func (tb *ttlBuffer[T]) IsEmpty() bool {
+	return tb.Size() == 0
+}
+
+// Capacity returns the maximum number of elements the buffer can store.
+func (tb *ttlBuffer[T]) Capacity() int {
+	return tb.buf.Capacity()
+}
+
+// PurgeExpired discards the expired elements and returns their number.
+func (tb *ttlBuffer[T]) PurgeExpired() int {
+	tb.buf.mu.Lock()
+	purged := tb.purgeExpired()
+	tb.buf.mu.Unlock()
+
+	tb.buf.notifyPops(purged)
+	return purged
+}
+
+// purgeExpired discards the elements that are older than the buffer ttl.
+// Since the elements are pushed in time order, the expired ones are always
+// the oldest. The caller must hold the lock.
+func (tb *ttlBuffer[T]) purgeExpired() int {
+	now := tb.buf.now()
+	purged := 0
+	for tb.buf.size.Load() > 0 {
+		if now.Sub(tb.buf.data[tb.buf.readerIdx].pushedAt) <= tb.ttl {
+			break
+		}
+		tb.buf.pop()
+		purged++
+	}
+	return purged
+}
+
+// NewTTL returns a new thread-safe ring buffer with the given capacity, whose
+// elements expire after the given time to live. Only the options that don't
+// depend on the element type (WithClock and WithObserver) are applied. The
+// observer is notified of the expired elements discarded by the buffer as if
+// they were popped.
+// If the specified capacity is less than 1, returns ErrInvalidBuffCap.
+// If the ttl is not positive, returns ErrInvalidTTL.
+func NewTTL[T any](capacity int, ttl time.Duration, opts ...Option[T]) (tb *ttlBuffer[T], err error) {
+	if ttl <= 0 {
+		return tb, ErrInvalidTTL
+	}
+
+	var o options[T]
+	for _, opt := range opts {
+		opt(&o)
+	}
+	buf, err := New(capacity,
+		WithClock[timedItem[T]](o.now),
+		WithObserver[timedItem[T]](o.observer),
+	)
+	if err != nil {
+		return tb, err
+	}
+
+	return &ttlBuffer[T]{buf: buf, ttl: ttl}, nil
+}
@@ -0,0 +1,200 @@
+package buffer
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for time-dependent tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newTestTTLBuffer returns a TTL buffer that uses the given fake clock.
+func newTestTTLBuffer(t *testing.T, capacity int, ttl time.Duration, clock *fakeClock) *ttlBuffer[int] {
+	t.Helper()
+	buffer, err := NewTTL(capacity, ttl, WithClock[int](clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buffer
+}
+
+func TestNewTTL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		capacity int
+		ttl      time.Duration
+		wantErr  error
+	}{
+		{name: "valid", capacity: 3, ttl: time.Second, wantErr: nil},
+		{name: "zero capacity", capacity: 0, ttl: time.Second, wantErr: ErrInvalidBuffCap},
+		{name: "zero ttl", capacity: 3, ttl: 0, wantErr: ErrInvalidTTL},
+		{name: "negative ttl", capacity: 3, ttl: -time.Second, wantErr: ErrInvalidTTL},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewTTL[int](tc.capacity, tc.ttl)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("want error: %v, got error: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTTLBufferExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	buffer := newTestTTLBuffer(t, 5, 10*time.Second, clock)
+
+	buffer.Push(1) // t=0s
+	clock.Advance(4 * time.Second)
+	buffer.Push(2) // t=4s
+	clock.Advance(4 * time.Second)
+	buffer.Push(3) // t=8s
+
+	clock.Advance(2 * time.Second) // t=10s, the first element is exactly ttl old
+	if buffer.Size() != 3 {
+		t.Errorf("buffer size: want 3, got %d", buffer.Size())
+	}
+	if got, ok := buffer.Get(); !ok || got != 1 {
+		t.Errorf("Get(): want 1, got %d", got)
+	}
+
+	clock.Advance(time.Nanosecond) // the first element has expired
+	if buffer.Size() != 2 {
+		t.Errorf("buffer size: want 2, got %d", buffer.Size())
+	}
+	if got, ok := buffer.Get(); !ok || got != 2 {
+		t.Errorf("Get(): want 2, got %d", got)
+	}
+
+	clock.Advance(5 * time.Second) // t=15s, the second element has expired
+	if got, ok := buffer.Pop(); !ok || got != 3 {
+		t.Errorf("Pop(): want 3, got %d", got)
+	}
+
+	buffer.Push(4) // t=15s
+	clock.Advance(11 * time.Second)
+	if _, ok := buffer.Pop(); ok {
+		t.Errorf("expected ok: false for expired elements")
+	}
+	if !buffer.IsEmpty() {
+		t.Errorf("empty buffer expected")
+	}
+}
+
+func TestTTLBufferPurgeExpired(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	buffer := newTestTTLBuffer(t, 10, time.Minute, clock)
+
+	for i := 0; i < 6; i++ {
+		buffer.Push(i)
+		clock.Advance(20 * time.Second)
+	}
+	// The elements are 120s, 100s, 80s, 60s, 40s and 20s old.
+	if got := buffer.PurgeExpired(); got != 3 {
+		t.Errorf("purged elements: want 3, got %d", got)
+	}
+	if got := buffer.PurgeExpired(); got != 0 {
+		t.Errorf("purged elements: want 0, got %d", got)
+	}
+	if got, _ := buffer.Get(); got != 3 {
+		t.Errorf("Get(): want 3, got %d", got)
+	}
+
+	clock.Advance(time.Hour)
+	if got := buffer.PurgeExpired(); got != 3 {
+		t.Errorf("purged elements: want 3, got %d", got)
+	}
+	if buffer.Capacity() != 10 {
+		t.Errorf("buffer capacity: want 10, got %d", buffer.Capacity())
+	}
+}
+
+func TestTTLBufferGetLastWithAge(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	buffer := newTestTTLBuffer(t, 3, time.Minute, clock)
+
+	if got, age, ok := buffer.GetLastWithAge(); ok || got != 0 || age != 0 {
+		t.Errorf("GetLastWithAge(): want 0, 0, false, got %d, %v, %t", got, age, ok)
+	}
+
+	buffer.Push(1)
+	clock.Advance(10 * time.Second)
+	buffer.Push(2)
+	clock.Advance(15 * time.Second)
+	if got, age, ok := buffer.GetLastWithAge(); !ok || got != 2 || age != 15*time.Second {
+		t.Errorf("GetLastWithAge(): want 2, 15s, true, got %d, %v, %t", got, age, ok)
+	}
+	if buffer.Size() != 2 {
+		t.Errorf("buffer size: want 2, got %d", buffer.Size())
+	}
+
+	clock.Advance(time.Minute) // all elements have expired
+	if got, age, ok := buffer.GetLastWithAge(); ok || got != 0 || age != 0 {
+		t.Errorf("GetLastWithAge(): want 0, 0, false, got %d, %v, %t", got, age, ok)
+	}
+}
+
+func TestTTLBufferGetWithTime(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	buffer := newTestTTLBuffer(t, 3, time.Minute, clock)
+
+	if got, pushedAt, ok := buffer.GetWithTime(); ok || got != 0 || !pushedAt.IsZero() {
+		t.Errorf("GetWithTime(): want 0, zero time, false, got %d, %v, %t", got, pushedAt, ok)
+	}
+
+	buffer.Push(1)
+	clock.Advance(10 * time.Second)
+	buffer.Push(2)
+	clock.Advance(15 * time.Second)
+	if got, pushedAt, ok := buffer.GetWithTime(); !ok || got != 1 || !pushedAt.Equal(start) {
+		t.Errorf("GetWithTime(): want 1, %v, true, got %d, %v, %t", start, got, pushedAt, ok)
+	}
+	if buffer.Size() != 2 {
+		t.Errorf("buffer size: want 2, got %d", buffer.Size())
+	}
+
+	clock.Advance(40 * time.Second) // the first element has expired
+	want := start.Add(10 * time.Second)
+	if got, pushedAt, ok := buffer.GetWithTime(); !ok || got != 2 || !pushedAt.Equal(want) {
+		t.Errorf("GetWithTime(): want 2, %v, true, got %d, %v, %t", want, got, pushedAt, ok)
+	}
+}
+
+func TestTTLBufferObserver(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	observer := &countingObserver{}
+	buffer, err := NewTTL(2, time.Minute, WithClock[int](clock.Now), WithObserver[int](observer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer.Push(1)
+	buffer.Push(2) // fills the buffer
+	buffer.Push(3) // overwrites
+	buffer.Pop()
+	want := countingObserver{pushes: 3, pops: 1, overwrites: 1, fulls: 1}
+	if *observer != want {
+		t.Errorf("observer calls: want %+v, got %+v", want, *observer)
+	}
+
+	// The expired elements are reported as popped.
+	clock.Advance(2 * time.Minute)
+	buffer.Size()
+	want.pops++
+	if *observer != want {
+		t.Errorf("observer calls: want %+v, got %+v", want, *observer)
+	}
+}
@@ -0,0 +1,65 @@
+package buffer
+
+// ReadOnlyBuffer is the consuming side of a ring buffer, e.g. for code that
+// must not add elements.
+type ReadOnlyBuffer[T any] interface {
+	Pop() (T, bool)
+	Get() (T, bool)
+	Size() int
+	IsEmpty() bool
+	IsFull() bool
+	Capacity() int
+	Snapshot() []T
+}
+
+// WriteOnlyBuffer is the producing side of a ring buffer, e.g. for code that
+// must not consume elements.
+type WriteOnlyBuffer[T any] interface {
+	Push(item T)
+	TryPush(item T) error
+	PushSlice(items []T) []T
+	Size() int
+	IsEmpty() bool
+	IsFull() bool
+	Capacity() int
+}
+
+// readOnlyView restricts a ring buffer to ReadOnlyBuffer. Unlike the buffer
+// itself, it can't be converted back to the buffer by a type assertion.
+type readOnlyView[T any] struct {
+	rb *ringBuffer[T]
+}
+
+func (v readOnlyView[T]) Pop() (T, bool) { return v.rb.Pop() }
+func (v readOnlyView[T]) Get() (T, bool) { return v.rb.Get() }
+func (v readOnlyView[T]) Size() int      { return v.rb.Size() }
+func (v readOnlyView[T]) IsEmpty() bool  { return v.rb.IsEmpty() }
+func (v readOnlyView[T]) IsFull() bool   { return v.rb.IsFull() }
+func (v readOnlyView[T]) Capacity() int  { return v.rb.Capacity() }
+func (v readOnlyView[T]) Snapshot() []T  { return v.rb.Snapshot() }
+
+// writeOnlyView restricts a ring buffer to WriteOnlyBuffer. Unlike the buffer
+// itself, it can't be converted back to the buffer by a type assertion.
+type writeOnlyView[T any] struct {
+	rb *ringBuffer[T]
+}
+
+func (v writeOnlyView[T]) Push(item T)             { v.rb.Push(item) }
+func (v writeOnlyView[T]) TryPush(item T) error    { return v.rb.TryPush(item) }
+func (v writeOnlyView[T]) PushSlice(items []T) []T { return v.rb.PushSlice(items) }
+func (v writeOnlyView[T]) Size() int               { return v.rb.Size() }
+func (v writeOnlyView[T]) IsEmpty() bool           { return v.rb.IsEmpty() }
+func (v writeOnlyView[T]) IsFull() bool            { return v.rb.IsFull() }
+func (v writeOnlyView[T]) Capacity() int           { return v.rb.Capacity() }
+
+// ReadOnly returns a view of the buffer that only allows consuming the
+// elements, to hand the buffer to a consumer.
+func (rb *ringBuffer[T]) ReadOnly() ReadOnlyBuffer[T] {
+	return readOnlyView[T]{rb: rb}
+}
+
+// WriteOnly returns a view of the buffer that only allows adding elements, to
+// hand the buffer to a producer.
+func (rb *ringBuffer[T]) WriteOnly() WriteOnlyBuffer[T] {
+	return writeOnlyView[T]{rb: rb}
+}
@@ -0,0 +1,65 @@
+package buffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRingBufferReadOnly(t *testing.T) {
+	buffer := newWrappedBuffer(t, 3, 0, 1, 2, 3)
+	view := buffer.ReadOnly()
+
+	if _, ok := view.(interface{ Push(int) }); ok {
+		t.Errorf("read-only view must not expose Push")
+	}
+	if _, ok := view.(*ringBuffer[int]); ok {
+		t.Errorf("read-only view must not be convertible to the buffer")
+	}
+
+	if !view.IsFull() || view.IsEmpty() || view.Size() != 3 || view.Capacity() != 3 {
+		t.Errorf("view state: want full buffer of size 3, got size %d, capacity %d", view.Size(), view.Capacity())
+	}
+	if got, ok := view.Get(); !ok || got != 1 {
+		t.Errorf("Get(): want 1, true, got %d, %t", got, ok)
+	}
+	if got, ok := view.Pop(); !ok || got != 1 {
+		t.Errorf("Pop(): want 1, true, got %d, %t", got, ok)
+	}
+	want := []int{2, 3}
+	if got := view.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("view items: want %v, got %v", want, got)
+	}
+	if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("buffer items: want %v, got %v", want, got)
+	}
+}
+
+func TestRingBufferWriteOnly(t *testing.T) {
+	buffer := newWrappedBuffer[int](t, 3, 0)
+	view := buffer.WriteOnly()
+
+	if _, ok := view.(interface{ Pop() (int, bool) }); ok {
+		t.Errorf("write-only view must not expose Pop")
+	}
+	if _, ok := view.(*ringBuffer[int]); ok {
+		t.Errorf("write-only view must not be convertible to the buffer")
+	}
+
+	if !view.IsEmpty() || view.Capacity() != 3 {
+		t.Errorf("view state: want empty buffer of capacity 3, got size %d, capacity %d", view.Size(), view.Capacity())
+	}
+	view.Push(1)
+	if err := view.TryPush(2); err != nil {
+		t.Errorf("didn't expect an error: %v", err)
+	}
+	if evicted := view.PushSlice([]int{3, 4}); !reflect.DeepEqual(evicted, []int{1}) {
+		t.Errorf("evicted items: want [1], got %v", evicted)
+	}
+	if !view.IsFull() || view.Size() != 3 {
+		t.Errorf("view state: want full buffer of size 3, got size %d", view.Size())
+	}
+	want := []int{2, 3, 4}
+	if got := buffer.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("buffer items: want %v, got %v", want, got)
+	}
+}
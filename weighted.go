@@ -0,0 +1,111 @@
+package buffer
+
+import "fmt"
+
+var ErrInvalidMaxWeight = fmt.Errorf("max weight is less than 1")
+var ErrInvalidWeight = fmt.Errorf("item weight is negative or exceeds max weight")
+
+// weightedItem is a buffer element along with its weight.
+type weightedItem[T any] struct {
+	item   T
+	weight int
+}
+
+// weightedBuffer is a thread-safe ring buffer bounded by the total weight of
+// its elements rather than by their number, e.g. the total length of the
+// buffered byte slices. Pushing an element evicts as many oldest elements as
+// needed to keep the total weight within the limit.
+type weightedBuffer[T any] struct {
+	buf       *ringBuffer[weightedItem[T]]
+	weigh     func(T) int
+	weight    int
+	maxWeight int
+}
+
+// Push adds an element to the buffer, evicting the oldest elements until the
+// total weight, including the new element, fits into the max weight. Since
+// zero-weight elements don't count towards the limit, the number of elements
+// is limited by the max weight as well, so that they can't grow the buffer
+// without bound. If the element weight is negative or exceeds the max weight,
+// returns ErrInvalidWeight without adding the element.
+func (wb *weightedBuffer[T]) Push(item T) error {
+	w := wb.weigh(item)
+	if w < 0 || w > wb.maxWeight {
+		return ErrInvalidWeight
+	}
+
+	wb.buf.mu.Lock()
+	defer wb.buf.mu.Unlock()
+	for wb.weight+w > wb.maxWeight || int(wb.buf.size.Load()) >= wb.maxWeight {
+		evicted, _ := wb.buf.pop()
+		wb.weight -= evicted.weight
+	}
+	// The number of elements is limited by their weight, so make room
+	// instead of overwriting.
+	if wb.buf.isFull() {
+		wb.buf.realloc(min(2*int(wb.buf.cap.Load()), wb.maxWeight))
+	}
+	wb.buf.push(weightedItem[T]{item: item, weight: w})
+	wb.weight += w
+	return nil
+}
+
+// Pop removes and returns an element from the beginning of the buffer.
+// If the buffer is empty, returns an empty value and false.
+func (wb *weightedBuffer[T]) Pop() (T, bool) {
+	wb.buf.mu.Lock()
+	defer wb.buf.mu.Unlock()
+	entry, ok := wb.buf.pop()
+	wb.weight -= entry.weight
+	return entry.item, ok
+}
+
+// Get returns an element from the beginning of the buffer, but does not
+// remove it.
+func (wb *weightedBuffer[T]) Get() (T, bool) {
+	entry, ok := wb.buf.Get()
+	return entry.item, ok
+}
+
+// IsEmpty checks if the buffer is empty.
+func (wb *weightedBuffer[T]) IsEmpty() bool {
+	return wb.buf.IsEmpty()
+}
+
+// Size returns the number of elements in the buffer.
+func (wb *weightedBuffer[T]) Size() int {
+	return wb.buf.Size()
+}
+
+// Weight returns the total weight of the elements in the buffer.
+func (wb *weightedBuffer[T]) Weight() int {
+	wb.buf.mu.RLock()
+	defer wb.buf.mu.RUnlock()
+	return wb.weight
+}
+
+// MaxWeight returns the maximum total weight of the elements in the buffer.
+func (wb *weightedBuffer[T]) MaxWeight() int {
+	return wb.maxWeight
+}
+
+// NewWeighted returns a new thread-safe ring buffer that keeps the total
+// weight of its elements, as returned by weigh, within maxWeight. If weigh is
+// nil, each element weighs 1.
+// If maxWeight is less than 1, returns ErrInvalidMaxWeight.
+func NewWeighted[T any](maxWeight int, weigh func(T) int) (wb *weightedBuffer[T], err error) {
+	if maxWeight < 1 {
+		return wb, ErrInvalidMaxWeight
+	}
+	// The buffer grows on demand, so start small.
+	buf, err := New[weightedItem[T]](min(maxWeight, 16))
+	if err != nil {
+		return wb, err
+	}
+
+	if weigh == nil {
+		weigh = func(T) int { return 1 }
+	}
+
+	return &weightedBuffer[T]{buf: buf, weigh: weigh, maxWeight: maxWeight}, nil
+}
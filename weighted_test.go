@@ -0,0 +1,161 @@
+package buffer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewWeighted(t *testing.T) {
+	_, err := NewWeighted(0, func(s string) int { return len(s) })
+	if !errors.Is(err, ErrInvalidMaxWeight) {
+		t.Errorf("want error: %v, got error: %v", ErrInvalidMaxWeight, err)
+	}
+
+	buffer, err := NewWeighted(10, func(s string) int { return len(s) })
+	if err != nil {
+		t.Fatalf("didn't expect an error: %v", err)
+	}
+	if buffer.MaxWeight() != 10 {
+		t.Errorf("max weight: want 10, got %d", buffer.MaxWeight())
+	}
+}
+
+func TestWeightedBufferPush(t *testing.T) {
+	testCases := []struct {
+		name       string
+		maxWeight  int
+		items      []string
+		wantItems  []string
+		wantWeight int
+	}{
+		{
+			name:       "fits",
+			maxWeight:  10,
+			items:      []string{"ab", "cde", "f"},
+			wantItems:  []string{"ab", "cde", "f"},
+			wantWeight: 6,
+		},
+		{
+			name:       "exact fit",
+			maxWeight:  6,
+			items:      []string{"ab", "cde", "f"},
+			wantItems:  []string{"ab", "cde", "f"},
+			wantWeight: 6,
+		},
+		{
+			name:       "evicts one",
+			maxWeight:  6,
+			items:      []string{"ab", "cde", "fg"},
+			wantItems:  []string{"cde", "fg"},
+			wantWeight: 5,
+		},
+		{
+			name:       "evicts several",
+			maxWeight:  6,
+			items:      []string{"a", "b", "c", "d", "efghi"},
+			wantItems:  []string{"d", "efghi"},
+			wantWeight: 6,
+		},
+		{
+			name:       "evicts all",
+			maxWeight:  5,
+			items:      []string{"ab", "cd", "efghi"},
+			wantItems:  []string{"efghi"},
+			wantWeight: 5,
+		},
+		{
+			name:       "zero weight items",
+			maxWeight:  4,
+			items:      []string{"", "", "a", ""},
+			wantItems:  []string{"", "", "a", ""},
+			wantWeight: 1,
+		},
+		{
+			// The number of elements is limited by the max weight too.
+			name:       "zero weight items over limit",
+			maxWeight:  2,
+			items:      []string{"", "", "a", ""},
+			wantItems:  []string{"a", ""},
+			wantWeight: 1,
+		},
+		{
+			name:       "many light items",
+			maxWeight:  40,
+			items:      []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "q", "r", "s", "t"},
+			wantItems:  []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "q", "r", "s", "t"},
+			wantWeight: 20,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, err := NewWeighted(tc.maxWeight, func(s string) int { return len(s) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, item := range tc.items {
+				if err := buffer.Push(item); err != nil {
+					t.Fatalf("didn't expect an error: %v", err)
+				}
+			}
+
+			if buffer.Weight() != tc.wantWeight {
+				t.Errorf("buffer weight: want %d, got %d", tc.wantWeight, buffer.Weight())
+			}
+			if buffer.Size() != len(tc.wantItems) {
+				t.Errorf("buffer size: want %d, got %d", len(tc.wantItems), buffer.Size())
+			}
+			var got []string
+			for !buffer.IsEmpty() {
+				item, _ := buffer.Pop()
+				got = append(got, item)
+			}
+			if !reflect.DeepEqual(got, tc.wantItems) {
+				t.Errorf("buffer items: want %q, got %q", tc.wantItems, got)
+			}
+			if buffer.Weight() != 0 {
+				t.Errorf("buffer weight: want 0, got %d", buffer.Weight())
+			}
+		})
+	}
+}
+
+func TestWeightedBufferInvalidWeight(t *testing.T) {
+	buffer, err := NewWeighted(3, func(n int) int { return n })
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer.Push(2)
+
+	for _, item := range []int{4, -1} {
+		if err := buffer.Push(item); !errors.Is(err, ErrInvalidWeight) {
+			t.Errorf("Push(%d): want error: %v, got error: %v", item, ErrInvalidWeight, err)
+		}
+	}
+	if buffer.Weight() != 2 || buffer.Size() != 1 {
+		t.Errorf("buffer should be unchanged, got weight: %d, size: %d", buffer.Weight(), buffer.Size())
+	}
+	if got, _ := buffer.Get(); got != 2 {
+		t.Errorf("Get(): want 2, got %d", got)
+	}
+}
+
+func TestWeightedBufferZeroWeight(t *testing.T) {
+	buffer, err := NewWeighted(20, func(b []byte) int { return len(b) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if err := buffer.Push(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The number of elements must be limited by the max weight.
+	if buffer.Size() != 20 || buffer.Weight() != 0 {
+		t.Errorf("want size 20, weight 0, got size %d, weight %d", buffer.Size(), buffer.Weight())
+	}
+	if got := buffer.buf.Capacity(); got != 20 {
+		t.Errorf("buffer capacity: want 20, got %d", got)
+	}
+}